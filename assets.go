@@ -0,0 +1,127 @@
+package main
+
+import (
+	"github.com/gen2brain/raylib-go/raylib"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+)
+
+// The assets are embedded in the binary so the game runs from any directory.
+// Asset paths are relative to the assets directory and use slashes, e.g. "textures/title.png".
+// With -assets {dir}, the files found in dir replace the embedded ones of the same path.
+
+//go:embed assets
+var embeddedAssets embed.FS
+
+var assetOverrideDir string
+
+func readAsset(name string) ([]byte, error) {
+	if assetOverrideDir != "" {
+		data, err := os.ReadFile(filepath.Join(assetOverrideDir, filepath.FromSlash(name)))
+		if err == nil { return data, nil }
+		if !errors.Is(err, fs.ErrNotExist) { return nil, err }
+	}
+	return embeddedAssets.ReadFile(path.Join("assets", name))
+}
+
+// Returns the entries of the asset directory, the ones of the override directory
+// first and then the embedded ones not overridden, sorted by name within each
+func listAssetDir(name string) []fs.DirEntry {
+	var entries []fs.DirEntry
+	seen := map[string]bool{}
+	if assetOverrideDir != "" {
+		override, _ := os.ReadDir(filepath.Join(assetOverrideDir, filepath.FromSlash(name)))
+		for _, e := range override { entries, seen[e.Name()] = append(entries, e), true }
+	}
+	embedded, _ := embeddedAssets.ReadDir(path.Join("assets", name))
+	for _, e := range embedded {
+		if !seen[e.Name()] { entries = append(entries, e) }
+	}
+	return entries
+}
+
+// Returns the image of the asset, or an empty one if it can't be read or decoded
+func loadImageAsset(name string) (*rl.Image, error) {
+	data, err := readAsset(name)
+	if err != nil { return &rl.Image{}, err }
+	if len(data) == 0 { return &rl.Image{}, errors.New("the file is empty") }
+	image := rl.LoadImageFromMemory(path.Ext(name), data, i32(len(data)))
+	if image.Width == 0 || image.Height == 0 { return image, errors.New("the image can't be decoded") }
+	return image, nil
+}
+
+// Returns the sound of the asset, or an empty(silent) one if it can't be read or decoded
+func loadSoundAsset(name string) (rl.Sound, error) {
+	data, err := readAsset(name)
+	if err != nil { return rl.Sound{}, err }
+	if len(data) == 0 { return rl.Sound{}, errors.New("the file is empty") }
+	wave := rl.LoadWaveFromMemory(path.Ext(name), data, i32(len(data)))
+	defer rl.UnloadWave(wave)
+	sound := rl.LoadSoundFromWave(wave)
+	if sound.FrameCount == 0 { return sound, errors.New("the sound can't be decoded") }
+	return sound, nil
+}
+
+// The assets that failed to load, printed together once loadAssets is done
+type AssetReport struct {
+	failures []string
+}
+
+func (r *AssetReport) fail(name string, err error, fallback string) {
+	r.failures = append(r.failures, fmt.Sprintf("  %s: %v (%s)", name, err, fallback))
+}
+
+func (r *AssetReport) print() {
+	if len(r.failures) == 0 { return }
+	fmt.Printf("%d asset(s) failed to load:\n", len(r.failures))
+	for _, f := range r.failures { fmt.Println(f) }
+}
+
+// Placeholders for the textures that fail to load, at the size they are used
+
+var placeholderColors = [NUM_COLOR]rl.Color{rl.Red, rl.Lime, rl.SkyBlue, rl.Gold}
+
+// A sheet of colored rectangles in the layout of animals.png, a color per row
+// and the letter of the kind(A to D) in each column
+func genAnimalsImage() *rl.Image {
+	image := rl.GenImageColor(int(ANIM_SIZE*NUM_COL), int(ANIM_SIZE*NUM_ROW), rl.Blank)
+	inset := ANIM_SIZE/10
+	fontSize := i32(ROW_HEIGHT/4)
+	for row := 0; row < NUM_ROW; row++ {
+		for col := 0; col < NUM_COL; col++ {
+			x, y := f32(col)*ANIM_SIZE, f32(row)*ANIM_SIZE
+			rect := rl.Rectangle{x + inset, y + inset, ANIM_SIZE - 2*inset, ANIM_SIZE - 2*inset}
+			rl.ImageDrawRectangleRec(image, rect, placeholderColors[row])
+
+			letter := string(rune('A' + col))
+			width := f32(rl.MeasureText(letter, fontSize))
+			rl.ImageDrawText(image, i32(x + (ANIM_SIZE - width)/2), i32(y + (ANIM_SIZE - f32(fontSize))/2),
+							 letter, fontSize, rl.RayWhite)
+		}
+	}
+	return image
+}
+
+func genGroundImage() *rl.Image {
+	image := rl.GenImageColor(WINDOW_WIDTH, WINDOW_HEIGHT, rl.DarkGreen)
+	rl.ImageDrawRectangle(image, 0, UPPER_LAND_HEIGHT, WINDOW_WIDTH, WINDOW_HEIGHT - UPPER_LAND_HEIGHT,
+						  rl.DarkBrown)
+	return image
+}
+
+func genTitleImage() *rl.Image {
+	image := rl.GenImageColor(TITLE_WIDTH, TITLE_HEIGHT, rl.Blank)
+	const text, fontSize = "Animal Logic", TITLE_HEIGHT/4
+	width := rl.MeasureText(text, fontSize)
+	rl.ImageDrawText(image, (TITLE_WIDTH - width)/2, (TITLE_HEIGHT - fontSize)/2, text, fontSize, rl.Gold)
+	return image
+}
+
+func genDustImage() *rl.Image {
+	return rl.GenImageColor(DUST_IMAGE_WIDTH, DUST_IMAGE_HEIGHT, rl.Fade(rl.LightGray, 0.6))
+}
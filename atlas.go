@@ -0,0 +1,87 @@
+package main
+
+import (
+	"github.com/gen2brain/raylib-go/raylib"
+	"encoding/json"
+	"fmt"
+	"path"
+)
+
+// A sprite atlas maps each color and kind of the animals to a rectangle of its image,
+// so a sheet can have any layout and resolution. It's loaded from a JSON manifest:
+//     {"image": "animals.png", "sprites": [{"color": 0, "kind": 0, "x": 768, "y": 768, "w": 256, "h": 256}, ...]}
+// image is relative to the manifest. color and kind are the bit indexes of the type
+// of an animal(see setAnimals), and every pair of them needs a sprite.
+type Atlas struct {
+	Image string `json:"image"`
+	Sprites []Sprite `json:"sprites"`
+	rects [NUM_COLOR][NUM_KIND]rl.Rectangle
+}
+
+type Sprite struct {
+	Color int `json:"color"`
+	Kind int `json:"kind"`
+	X f32 `json:"x"`
+	Y f32 `json:"y"`
+	W f32 `json:"w"`
+	H f32 `json:"h"`
+}
+
+func loadAtlas(name string) (Atlas, error) {
+	data, err := readAsset(name)
+	if err != nil { return Atlas{}, err }
+
+	var atlas Atlas
+	if err := json.Unmarshal(data, &atlas); err != nil { return Atlas{}, err }
+	if atlas.Image == "" { return Atlas{}, fmt.Errorf("no image") }
+	atlas.Image = path.Join(path.Dir(name), atlas.Image)
+
+	var found [NUM_COLOR][NUM_KIND]bool
+	for i, s := range atlas.Sprites {
+		if s.Color < 0 || s.Color >= NUM_COLOR || s.Kind < 0 || s.Kind >= NUM_KIND {
+			return Atlas{}, fmt.Errorf("sprite %d: color %d, kind %d is out of range", i, s.Color, s.Kind)
+		}
+		if s.W <= 0 || s.H <= 0 { return Atlas{}, fmt.Errorf("sprite %d: the size is not positive", i) }
+		if found[s.Color][s.Kind] {
+			return Atlas{}, fmt.Errorf("sprite %d: color %d, kind %d has another sprite", i, s.Color, s.Kind)
+		}
+		found[s.Color][s.Kind] = true
+		atlas.rects[s.Color][s.Kind] = rl.Rectangle{s.X, s.Y, s.W, s.H}
+	}
+	for color := 0; color < NUM_COLOR; color++ {
+		for kind := 0; kind < NUM_KIND; kind++ {
+			if !found[color][kind] { return Atlas{}, fmt.Errorf("no sprite for color %d, kind %d", color, kind) }
+		}
+	}
+	return atlas, nil
+}
+
+// Checks that all the sprites are inside the image
+func (a *Atlas) fits(image *rl.Image) error {
+	for _, s := range a.Sprites {
+		if s.X < 0 || s.Y < 0 || s.X + s.W > f32(image.Width) || s.Y + s.H > f32(image.Height) {
+			return fmt.Errorf("the sprite of color %d, kind %d is outside the %dx%d image",
+							  s.Color, s.Kind, image.Width, image.Height)
+		}
+	}
+	return nil
+}
+
+// The atlas of a sheet laid out like animals.png: kinds in columns and colors in rows,
+// both from the highest bit
+func gridAtlas(cellWidth, cellHeight f32) Atlas {
+	var atlas Atlas
+	for color := 0; color < NUM_COLOR; color++ {
+		for kind := 0; kind < NUM_KIND; kind++ {
+			x, y := f32(NUM_KIND - 1 - kind) * cellWidth, f32(NUM_COLOR - 1 - color) * cellHeight
+			atlas.Sprites = append(atlas.Sprites, Sprite{color, kind, x, y, cellWidth, cellHeight})
+			atlas.rects[color][kind] = rl.Rectangle{x, y, cellWidth, cellHeight}
+		}
+	}
+	return atlas
+}
+
+// Returns the source rectangle of the animal type
+func (a *Atlas) rect(animType u8) rl.Rectangle {
+	return a.rects[findFirst1Bit(animType >> NUM_KIND)][findFirst1Bit(animType & 0b1111)]
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"github.com/gen2brain/raylib-go/raylib"
+	"fmt"
+	"reflect"
+)
+
+// Every sound is played by name through the audio manager, which sets its volume
+// from the buses: the master bus scales the SFX and the music buses.
+// The volumes and the mute are kept in the settings. The sounds of the animals are
+// panned by their X position and get louder with the height of their jump.
+type Bus int
+const (
+	BUS_MASTER Bus = iota
+	BUS_SFX
+	BUS_MUSIC
+)
+
+const (
+	VOLUME_STEP = 0.1
+	PAN_SPREAD = 0.8              // of the pan range covered from the left to the right edge
+	JUMP_VOLUME_RANGE = 0.3       // the volume of a flat jump is 1 - JUMP_VOLUME_RANGE
+	LOUDEST_JUMP_HEIGHT = 300     // in pixels
+)
+
+// Returns the sound with the field name of Sounds, e.g. "TitleLand"
+func soundByName(name string) (*rl.Sound, bool) {
+	if name == "" { return nil, false }
+	field := reflect.ValueOf(&sounds).Elem().FieldByName(name)
+	if !field.IsValid() { return nil, false }
+	sound, ok := field.Addr().Interface().(*rl.Sound)
+	return sound, ok
+}
+
+func playSound(name string) { playPannedSound(name, 0.5, 1) }
+
+// Plays the sound of something at x on the board that jumped jumpHeight pixels high
+func playSoundAt(name string, x, jumpHeight f32) {
+	// raylib pans from the right(0) to the left(1)
+	pan := 0.5 + (0.5 - x/WINDOW_WIDTH)*PAN_SPREAD
+	h := jumpHeight/LOUDEST_JUMP_HEIGHT
+	if h < 0 { h = 0 }
+	if h > 1 { h = 1 }
+	playPannedSound(name, pan, 1 - JUMP_VOLUME_RANGE*(1 - h))
+}
+
+func playPannedSound(name string, pan, gain f32) {
+	sound, ok := soundByName(name)
+	if !ok {
+		if DEBUG { fmt.Printf("No sound named %q\n", name) }
+		return
+	}
+	if settings.Muted { return }
+	rl.SetSoundVolume(*sound, busVolume(BUS_SFX) * gain)
+	rl.SetSoundPan(*sound, pan)
+	rl.PlaySound(*sound)
+	if duckingSounds[name] { duckMusic(*sound) }
+}
+
+// Plays the sounds of the gameplay events
+func subscribeAudio() {
+	subscribe(EVENT_RESCUED, func(e Event) {
+		if !e.anim.bigJump { playSoundAt("Jump", e.from.X, e.anim.jump.height(e.from.Y)) }
+	})
+	subscribe(EVENT_BIG_JUMP_STARTED, func(e Event) {
+		playSoundAt("BigJump", e.from.X, e.anim.jump.height(e.from.Y))
+	})
+	subscribe(EVENT_LANDED, func(e Event) {
+		height := e.anim.jump.height(e.to.Y)
+		// back on the front row after a big jump
+		if gameMode == GAME_PLAY && e.from.Y > e.to.Y && e.to.Y == FRONT_ROW_Y {
+			playSoundAt("Yay", e.to.X, height)
+		}
+
+		switch {
+		case e.veloc <= FPS/2:
+		case e.veloc <= FPS:
+			if gameMode == GAME_PLAY { playSoundAt("Land", e.to.X, height) }
+		case e.to == Vec2{RESQUE_SPOT_X, RESQUE_SPOT_Y}:
+			playSoundAt("BigLand", e.to.X, height)
+		default:
+			playSoundAt("Land", e.to.X, height)
+		}
+	})
+	subscribe(EVENT_CLEARED, func(e Event) { playSound("Success") })
+	subscribe(EVENT_DEAD_END, func(e Event) { playSound("Fail") })
+}
+
+// Returns the volume of the bus scaled by the master volume, 0 when muted
+func busVolume(bus Bus) f32 {
+	if settings.Muted { return 0 }
+	v := &settings.Volumes
+	switch bus {
+	case BUS_SFX:
+		return v.Master * v.SFX
+	case BUS_MUSIC:
+		return v.Master * v.Music
+	}
+	return v.Master
+}
+
+func toggleMute() {
+	settings.Muted = !settings.Muted
+	saveSettingsOrReport()
+}
+
+// Changes the master volume by delta, within [0, 1]
+func changeVolume(delta f32) {
+	v := &settings.Volumes
+	v.Master += delta
+	if v.Master < 0 { v.Master = 0 }
+	if v.Master > 1 { v.Master = 1 }
+	saveSettingsOrReport()
+}
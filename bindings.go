@@ -0,0 +1,224 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Gameplay reads actions instead of keys. The keys and gamepad buttons of each action
+// are kept in bindings.json next to the settings file, by name:
+//     {"rescue1": ["A", "1", "PadLeft"], "replay": ["G", "PadRT"], "start": ["Space"], ...}
+// Actions missing from the file keep their default keys.
+type Action int
+const (
+	ACTION_RESCUE1 Action = iota
+	ACTION_RESCUE2
+	ACTION_RESCUE3
+	ACTION_RESCUE4
+	ACTION_REPLAY
+	ACTION_START
+	ACTION_UNDO
+	ACTION_HINT
+	ACTION_PAUSE
+	ACTION_CURSOR_LEFT
+	ACTION_CURSOR_RIGHT
+	ACTION_CURSOR_RESCUE
+	NUM_ACTIONS
+)
+
+type Bindings [NUM_ACTIONS][]i32
+
+var actionNames = [NUM_ACTIONS]string{
+	"rescue1", "rescue2", "rescue3", "rescue4", "replay", "start", "undo", "hint", "pause",
+	"cursorLeft", "cursorRight", "cursorRescue",
+}
+
+// The D-pad and the face buttons(in the same directions) rescue from the left to the right
+// in the order left, up, down, right. The other actions have buttons of their own, as bind
+// takes a button away from every other action.
+var defaultBindings = Bindings{
+	ACTION_RESCUE1: {KEY_A, padCode(GAMEPAD_BUTTON_LEFT_FACE_LEFT), padCode(GAMEPAD_BUTTON_RIGHT_FACE_LEFT)},
+	ACTION_RESCUE2: {KEY_S, padCode(GAMEPAD_BUTTON_LEFT_FACE_UP), padCode(GAMEPAD_BUTTON_RIGHT_FACE_UP)},
+	ACTION_RESCUE3: {KEY_D, padCode(GAMEPAD_BUTTON_LEFT_FACE_DOWN), padCode(GAMEPAD_BUTTON_RIGHT_FACE_DOWN)},
+	ACTION_RESCUE4: {KEY_F, padCode(GAMEPAD_BUTTON_LEFT_FACE_RIGHT), padCode(GAMEPAD_BUTTON_RIGHT_FACE_RIGHT)},
+	ACTION_REPLAY: {KEY_G, padCode(GAMEPAD_BUTTON_RIGHT_TRIGGER_2)},
+	ACTION_START: {KEY_SPACE, padCode(GAMEPAD_BUTTON_MIDDLE_RIGHT)},
+	ACTION_UNDO: {KEY_U, padCode(GAMEPAD_BUTTON_LEFT_TRIGGER_1)},
+	ACTION_HINT: {KEY_H, padCode(GAMEPAD_BUTTON_RIGHT_TRIGGER_1)},
+	ACTION_PAUSE: {KEY_P, padCode(GAMEPAD_BUTTON_LEFT_TRIGGER_2)},
+	ACTION_CURSOR_LEFT: {KEY_LEFT},
+	ACTION_CURSOR_RIGHT: {KEY_RIGHT},
+	ACTION_CURSOR_RESCUE: {KEY_SPACE},
+}
+
+var bindings = defaultBindings
+
+// The player's bindings, kept aside while a replay or a script plays so that the bindings
+// it plays with aren't saved over them
+var playerBindings *Bindings
+
+// The keys that aren't rebindable: the menus and the audio/theme toggles
+var fixedKeys = []i32{KEY_Q, KEY_T, KEY_M, KEY_MINUS, KEY_EQUAL, KEY_ENTER, KEY_BACKSPACE, KEY_F1,
+					  KEY_DOWN, KEY_UP}
+var fixedPadButtons = []i32{GAMEPAD_BUTTON_MIDDLE_LEFT}
+
+var keyNames = map[i32]string{
+	KEY_SPACE: "Space", KEY_ENTER: "Enter", KEY_BACKSPACE: "Backspace", KEY_TAB: "Tab",
+	KEY_RIGHT: "Right", KEY_LEFT: "Left", KEY_DOWN: "Down", KEY_UP: "Up",
+	KEY_MINUS: "-", KEY_EQUAL: "=", KEY_COMMA: ",", KEY_PERIOD: ".", KEY_SLASH: "/",
+	KEY_SEMICOLON: ";", KEY_APOSTROPHE: "'", KEY_LEFT_BRACKET: "[", KEY_RIGHT_BRACKET: "]",
+	KEY_LEFT_SHIFT: "LeftShift", KEY_LEFT_CONTROL: "LeftCtrl", KEY_LEFT_ALT: "LeftAlt",
+	KEY_RIGHT_SHIFT: "RightShift", KEY_RIGHT_CONTROL: "RightCtrl", KEY_RIGHT_ALT: "RightAlt",
+}
+
+// Returns the name of the input code, a key or a gamepad button
+func keyName(key i32) string {
+	if key >= PAD_CODE_BASE {
+		if name, ok := padButtonNames[key - PAD_CODE_BASE]; ok { return name }
+		return "Pad" + strconv.Itoa(int(key - PAD_CODE_BASE))
+	}
+	switch {
+	case key >= KEY_A && key <= KEY_Z, key >= KEY_ZERO && key <= KEY_NINE:
+		return string(rune(key))
+	case key >= KEY_F1 && key <= KEY_F12:
+		return "F" + strconv.Itoa(int(key - KEY_F1 + 1))
+	}
+	if name, ok := keyNames[key]; ok { return name }
+	return "Key" + strconv.Itoa(int(key))
+}
+
+func keyByName(name string) (i32, bool) {
+	for key := i32(1); key < PAD_CODE_BASE + NUM_PAD_BUTTONS; key++ {
+		if strings.EqualFold(keyName(key), name) { return key, true }
+	}
+	return 0, false
+}
+
+func isActionDown(action Action) bool {
+	for _, code := range bindings[action] {
+		if isInputDown(code) { return true }
+	}
+	return false
+}
+
+func isActionReleased(action Action) bool {
+	for _, code := range bindings[action] {
+		if isInputReleased(code) { return true }
+	}
+	return false
+}
+
+// Returns the names of the keys of the action, e.g. "G" or "G/R"
+func actionKeyNames(action Action) string {
+	names := make([]string, len(bindings[action]))
+	for i, key := range bindings[action] { names[i] = keyName(key) }
+	return strings.Join(names, "/")
+}
+
+// Replaces {action} in the lines with the keys of the action, e.g. "Press {replay}"
+func expandBindings(lines []string) []string {
+	var pairs []string
+	for action, name := range actionNames { pairs = append(pairs, "{" + name + "}", actionKeyNames(Action(action))) }
+	replacer := strings.NewReplacer(pairs...)
+
+	expanded := make([]string, len(lines))
+	for i, line := range lines { expanded[i] = replacer.Replace(line) }
+	return expanded
+}
+
+// Binds the action to the key(or the gamepad button) alone among the keys(or buttons)
+// of the action, unbinding it from the other actions
+func bind(action Action, code i32) {
+	isPad := code >= PAD_CODE_BASE
+	for a := range bindings {
+		kept := []i32{}
+		for _, c := range bindings[a] {
+			if c == code || Action(a) == action && (c >= PAD_CODE_BASE) == isPad { continue }
+			kept = append(kept, c)
+		}
+		bindings[a] = kept
+	}
+	bindings[action] = append(bindings[action], code)
+	watchKeys()
+}
+
+// Whether a replay or a script is playing, with the player's bindings held aside
+func replaying() bool {
+	return playerBindings != nil
+}
+
+func holdPlayerBindings() {
+	held := bindings
+	playerBindings = &held
+}
+
+// Gives the player back the bindings held by holdPlayerBindings, once the replay or the script is over
+func restorePlayerBindings() {
+	if playerBindings == nil { return }
+	bindings, playerBindings = *playerBindings, nil
+	watchKeys()
+}
+
+func bindingsPath() (string, error) {
+	path, err := settingsPath()
+	if err != nil { return "", err }
+	return filepath.Join(filepath.Dir(path), "bindings.json"), nil
+}
+
+func loadBindings() {
+	defer watchKeys()
+	path, err := bindingsPath()
+	if err != nil { return }
+	data, err := os.ReadFile(path)
+	if err != nil { return }
+
+	var names map[string][]string
+	if err := json.Unmarshal(data, &names); err != nil {
+		fmt.Printf("Ignoring the bindings in %s: %v\n", path, err)
+		return
+	}
+	setBindingNames(names, path)
+}
+
+func saveBindings() error {
+	path, err := bindingsPath()
+	if err != nil { return err }
+	data, err := json.MarshalIndent(bindingNames(), "", "\t")
+	if err != nil { return err }
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil { return err }
+	return os.WriteFile(path, data, 0644)
+}
+
+// The bindings by action and key name, as in bindings.json
+func bindingNames() map[string][]string {
+	names := map[string][]string{}
+	for action, name := range actionNames {
+		names[name] = []string{}
+		for _, key := range bindings[action] { names[name] = append(names[name], keyName(key)) }
+	}
+	return names
+}
+
+// Binds the actions named in names, the others keep their keys. from is where the
+// names come from, for the warnings.
+func setBindingNames(names map[string][]string, from string) {
+	for action, name := range actionNames {
+		keyNames, ok := names[name]
+		if !ok { continue }
+		var keys []i32
+		for _, keyName := range keyNames {
+			key, ok := keyByName(keyName)
+			if !ok {
+				fmt.Printf("Ignoring the unknown key %q of %s in %s\n", keyName, name, from)
+				continue
+			}
+			keys = append(keys, key)
+		}
+		bindings[action] = keys
+	}
+	watchKeys()
+}
@@ -0,0 +1,157 @@
+// Command atlaspack packs the sprites of the animals, one PNG per color and kind,
+// into a sheet and writes its atlas manifest for the game.
+//
+//	go run ./cmd/atlaspack -in sprites -out assets/themes/winter/animals.png
+//
+// The sprites are named color{c}_kind{k}.png, with c and k the bit indexes of the
+// type of an animal(0 to 3). All of them must have the same size. The manifest is
+// written next to the sheet with the .json extension unless -manifest is given.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// As in the game
+const (
+	NUM_COLOR = 4
+	NUM_KIND = 4
+)
+
+// The manifest format read by loadAtlas in the game
+type Atlas struct {
+	Image string `json:"image"`
+	Sprites []Sprite `json:"sprites"`
+}
+
+type Sprite struct {
+	Color int `json:"color"`
+	Kind int `json:"kind"`
+	X int `json:"x"`
+	Y int `json:"y"`
+	W int `json:"w"`
+	H int `json:"h"`
+}
+
+func main() {
+	inDir := flag.String("in", "", "directory of the sprites")
+	outPath := flag.String("out", "", "path of the packed sheet(.png)")
+	manifestPath := flag.String("manifest", "", "path of the manifest, the sheet's with .json by default")
+	padding := flag.Int("padding", 2, "transparent pixels between the sprites")
+	flag.Parse()
+
+	if *inDir == "" || *outPath == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if *padding < 0 {
+		fmt.Fprintln(os.Stderr, "atlaspack: -padding must not be negative")
+		flag.Usage()
+		os.Exit(2)
+	}
+	if *manifestPath == "" { *manifestPath = strings.TrimSuffix(*outPath, filepath.Ext(*outPath)) + ".json" }
+
+	if err := pack(*inDir, *outPath, *manifestPath, *padding); err != nil {
+		fmt.Fprintln(os.Stderr, "atlaspack:", err)
+		os.Exit(1)
+	}
+}
+
+func pack(inDir, outPath, manifestPath string, padding int) error {
+	sprites, err := loadSprites(inDir)
+	if err != nil { return err }
+
+	// kinds in columns and colors in rows
+	size := sprites[0][0].Bounds().Size()
+	cellW, cellH := size.X + padding, size.Y + padding
+	sheet := image.NewNRGBA(image.Rect(0, 0, cellW*NUM_KIND - padding, cellH*NUM_COLOR - padding))
+	atlas := Atlas{}
+	for color := 0; color < NUM_COLOR; color++ {
+		for kind := 0; kind < NUM_KIND; kind++ {
+			src := sprites[color][kind]
+			at := image.Pt(kind*cellW, color*cellH)
+			draw.Draw(sheet, image.Rectangle{at, at.Add(size)}, src, src.Bounds().Min, draw.Src)
+			atlas.Sprites = append(atlas.Sprites, Sprite{color, kind, at.X, at.Y, size.X, size.Y})
+		}
+	}
+
+	atlas.Image, err = filepath.Rel(filepath.Dir(manifestPath), outPath)
+	if err != nil { return err }
+	atlas.Image = filepath.ToSlash(atlas.Image)
+
+	if err := writePNG(outPath, sheet); err != nil { return err }
+	data, err := json.MarshalIndent(&atlas, "", "\t")
+	if err != nil { return err }
+	if err := os.WriteFile(manifestPath, append(data, '\n'), 0644); err != nil { return err }
+
+	fmt.Printf("Packed %d sprites of %dx%d into %s(%dx%d) and %s\n", NUM_COLOR*NUM_KIND,
+			   size.X, size.Y, outPath, sheet.Rect.Dx(), sheet.Rect.Dy(), manifestPath)
+	return nil
+}
+
+// Loads the sprite of every color and kind, checking they all have the same size
+func loadSprites(dir string) (*[NUM_COLOR][NUM_KIND]image.Image, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil { return nil, err }
+
+	var sprites [NUM_COLOR][NUM_KIND]image.Image
+	var size image.Point
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".png" { continue }
+
+		var color, kind int
+		var rest string
+		n, _ := fmt.Sscanf(entry.Name(), "color%d_kind%d%s", &color, &kind, &rest)
+		if n != 3 || rest != ".png" {
+			fmt.Fprintln(os.Stderr, "Skipping", entry.Name() + ", not named color{c}_kind{k}.png")
+			continue
+		}
+		if color < 0 || color >= NUM_COLOR || kind < 0 || kind >= NUM_KIND {
+			return nil, fmt.Errorf("%s: color %d, kind %d is out of range", entry.Name(), color, kind)
+		}
+
+		img, err := readPNG(filepath.Join(dir, entry.Name()))
+		if err != nil { return nil, err }
+		if size == (image.Point{}) { size = img.Bounds().Size() }
+		if img.Bounds().Size() != size {
+			return nil, fmt.Errorf("%s is %v, the other sprites are %v", entry.Name(), img.Bounds().Size(), size)
+		}
+		sprites[color][kind] = img
+	}
+
+	var missing []string
+	for color := 0; color < NUM_COLOR; color++ {
+		for kind := 0; kind < NUM_KIND; kind++ {
+			if sprites[color][kind] == nil { missing = append(missing, fmt.Sprintf("color%d_kind%d.png", color, kind)) }
+		}
+	}
+	if len(missing) > 0 { return nil, fmt.Errorf("missing sprites: %s", strings.Join(missing, ", ")) }
+	return &sprites, nil
+}
+
+func readPNG(path string) (image.Image, error) {
+	f, err := os.Open(path)
+	if err != nil { return nil, err }
+	defer f.Close()
+	img, err := png.Decode(f)
+	if err != nil { return nil, fmt.Errorf("%s: %v", path, err) }
+	return img, nil
+}
+
+func writePNG(path string, img image.Image) error {
+	f, err := os.Create(path)
+	if err != nil { return err }
+	if err := png.Encode(f, img); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"github.com/gen2brain/raylib-go/raylib"
+)
+
+// The cursor is a second way to rescue with the keyboard: the cursor actions(the arrows
+// by default) move it across the front row, and the rescue action under it(Space) is
+// held to charge a big jump and released to rescue, like a key of the front row.
+// It shows up once it's moved and hides when the front row is played another way.
+type Cursor struct {
+	col int
+	visible bool
+}
+
+const (
+	CURSOR_SIZE = ANIM_SIZE*1.2
+	CURSOR_THICKNESS = 3
+)
+
+var cursor Cursor
+
+// Called on each step of GAME_PLAY
+func (c *Cursor) update() {
+	switch {
+	case isActionReleased(ACTION_CURSOR_LEFT):
+		c.col = (c.col + NUM_COL - 1) % NUM_COL
+		c.visible = true
+	case isActionReleased(ACTION_CURSOR_RIGHT):
+		c.col = (c.col + 1) % NUM_COL
+		c.visible = true
+	case isMouseButtonReleased(MOUSE_LEFT) || isActionReleased(ACTION_RESCUE1) ||
+		 isActionReleased(ACTION_RESCUE2) || isActionReleased(ACTION_RESCUE3) ||
+		 isActionReleased(ACTION_RESCUE4):
+		c.visible = false
+	}
+}
+
+// Whether the rescue action is held on col, i.e. the cursor charges the animal there
+func (c *Cursor) isDown(col int) bool {
+	return c.visible && c.col == col && isActionDown(ACTION_CURSOR_RESCUE)
+}
+
+func (c *Cursor) isReleased(col int) bool {
+	return c.visible && c.col == col && isActionReleased(ACTION_CURSOR_RESCUE)
+}
+
+// Frames the spot of the column, in gold if its animal can be rescued next
+func (c *Cursor) draw(frontRowPos *[NUM_COL]Vec2, resquableIndex *[NUM_COL]int) {
+	if !c.visible { return }
+	pos := frontRowPos[c.col]
+	rect := rl.Rectangle{pos.X - CURSOR_SIZE/2, pos.Y - CURSOR_SIZE/2, CURSOR_SIZE, CURSOR_SIZE}
+	color := rl.Fade(rl.Gray, 0.8)
+	if resquableIndex[c.col] != 0 { color = rl.Gold }
+	rl.DrawRectangleRoundedLines(rect, 0.2, 8, CURSOR_THICKNESS, color)
+}
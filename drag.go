@@ -0,0 +1,118 @@
+package main
+
+import (
+	"github.com/gen2brain/raylib-go/raylib"
+)
+
+// A rescuable animal of the front row can be dragged with the mouse to the rescue spot.
+// Until the mouse moves DRAG_THRESHOLD away from where it grabbed the animal, it's a
+// click: the animal charges while held and is rescued on release. Once dragged, the
+// animal springs back from the charge and follows the mouse, is rescued with a regular
+// jump if dropped over the spot and slides back to its place if dropped anywhere else.
+type Drag struct {
+	anim *Animal
+	grab Vec2      // the mouse position the animal was grabbed at
+	offset Vec2    // from the mouse to the animal
+	dragging bool  // moved past DRAG_THRESHOLD
+	dropped *Animal  // on the spot, on this step
+	released bool    // after dragging, on this step
+}
+
+const (
+	DRAG_THRESHOLD = ANIM_SIZE/4
+	DROP_RADIUS = ANIM_SIZE
+	DRAG_RETURN_DURATION = 0.5
+)
+
+var drag Drag
+
+func mousePos() Vec2 { return Vec2{f32(input.mouseX), f32(input.mouseY)} }
+
+func isOnRescueSpot(pos Vec2) bool {
+	return Vec2DistSq(pos, Vec2{RESQUE_SPOT_X, RESQUE_SPOT_Y}) <= DROP_RADIUS*DROP_RADIUS
+}
+
+// Called on each step of GAME_PLAY the animals are settled on, before the rescues
+func (d *Drag) update(board *[BOARD_SIZE]*Animal, resquableIndex *[NUM_COL]int) {
+	d.dropped, d.released = nil, false
+	mouse := mousePos()
+
+	if d.anim == nil {
+		if !isMouseButtonDown(MOUSE_LEFT) { return }
+		for col := 0; col < NUM_COL; col++ {
+			anim := board[FRONT_ROW_BASEINDEX + col]
+			if resquableIndex[col] != 0 && isAnimRectClicked(anim) {
+				*d = Drag{anim: anim, grab: mouse, offset: Vec2Sub(anim.pos, mouse)}
+				return
+			}
+		}
+		return
+	}
+
+	// rescued another way meanwhile
+	if !d.isInFrontRow(board) {
+		*d = Drag{}
+		return
+	}
+
+	if isMouseButtonDown(MOUSE_LEFT) {
+		if !d.dragging && Vec2DistSq(mouse, d.grab) > DRAG_THRESHOLD*DRAG_THRESHOLD {
+			d.dragging = true
+			cancelTweens(&d.anim.pos.X)
+			cancelTweens(&d.anim.pos.Y)
+			tweenTo(&d.anim.height, ANIM_SIZE, RECOVER_DURATION, easeInQuad)
+		}
+		if d.dragging { d.anim.pos = Vec2Add(mouse, d.offset) }
+		return
+	}
+
+	if !d.dragging {
+		*d = Drag{}
+		return
+	}
+	if isOnRescueSpot(d.anim.pos) {
+		*d = Drag{released: true, dropped: d.anim}
+		return
+	}
+	d.cancel()
+	d.released = true
+}
+
+// Sends the dragged animal back to its place, snapping into it
+func (d *Drag) cancel() {
+	if d.dragging {
+		tweenTo(&d.anim.pos.X, d.anim.dest.X, DRAG_RETURN_DURATION, easeOutElastic)
+		tweenTo(&d.anim.pos.Y, d.anim.dest.Y, DRAG_RETURN_DURATION, easeOutElastic)
+	}
+	*d = Drag{}
+}
+
+func (d *Drag) isInFrontRow(board *[BOARD_SIZE]*Animal) bool {
+	for col := 0; col < NUM_COL; col++ {
+		if board[FRONT_ROW_BASEINDEX + col] == d.anim { return true }
+	}
+	return false
+}
+
+// Whether the mouse holds the animal down to charge it. A dragged animal isn't charged.
+func (d *Drag) isHeld(anim *Animal) bool {
+	if !isMouseButtonDown(MOUSE_LEFT) || d.dragging { return false }
+	return isAnimRectClicked(anim)
+}
+
+// Whether the animal is clicked, i.e. released without being dragged
+func (d *Drag) isClicked(anim *Animal) bool {
+	return !d.released && isMouseButtonReleased(MOUSE_LEFT) && isAnimRectClicked(anim)
+}
+
+func (d *Drag) isDropped(anim *Animal) bool { return anim != nil && anim == d.dropped }
+
+// Rings the rescue spot while an animal is dragged, in gold when it's over the spot
+func (d *Drag) draw(interp f32) {
+	if !d.dragging { return }
+	color := rl.Fade(rl.RayWhite, 0.6)
+	if isOnRescueSpot(d.anim.pos) { color = rl.Gold }
+	rl.DrawRing(Vec2{RESQUE_SPOT_X, RESQUE_SPOT_Y}, DROP_RADIUS - CURSOR_THICKNESS, DROP_RADIUS, 0, 360, 48, color)
+	// over the rescued animals
+	drawAnimal(d.anim, interp)
+}
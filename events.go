@@ -0,0 +1,35 @@
+package main
+
+// Gameplay code emits events for what happens on the board, and the other systems
+// (audio, messages, stats...) subscribe to the kinds they react to. Subscribers are
+// called synchronously by emit, in the order they subscribed.
+type EventKind int
+const (
+	EVENT_RESCUED EventKind = iota  // anim jumps from the front row to the rescue spot
+	EVENT_BIG_JUMP_STARTED          // the rescue of anim is a big jump
+	EVENT_LANDED                    // anim lands at the end of a jump from `from` to `to`
+	EVENT_SCATTERED                 // a big jump of anim sent the rescued animals back
+	EVENT_CLEARED                   // all the animals are rescued
+	EVENT_DEAD_END                  // no animal can be rescued next
+	NUM_EVENT_KINDS
+)
+
+// anim.jump is the jump of RESCUED, BIG_JUMP_STARTED and LANDED while they're emitted.
+type Event struct {
+	kind EventKind
+	anim *Animal
+	from Vec2
+	to Vec2
+	veloc f32          // LANDED: the vertical velocity on landing, in pixels/frame
+	bigJumpsLeft int   // SCATTERED
+}
+
+var subscribers [NUM_EVENT_KINDS][]func(e Event)
+
+func subscribe(kind EventKind, fn func(e Event)) {
+	subscribers[kind] = append(subscribers[kind], fn)
+}
+
+func emit(e Event) {
+	for _, fn := range subscribers[e.kind] { fn(e) }
+}
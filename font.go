@@ -0,0 +1,114 @@
+package main
+
+import (
+	"github.com/gen2brain/raylib-go/raylib"
+	"fmt"
+	"path"
+	"sort"
+)
+
+// The font of the messages. A TTF/OTF font is looked up in the assets' FONT_DIR as {language}.ttf,
+// then as default.ttf, and raylib's built-in font(Latin-1 only) is used if neither exists.
+// Only the glyphs used by the string tables are rasterized, so CJK fonts stay small.
+// No font is shipped, so a language the built-in font can't draw falls back to DEFAULT_LANGUAGE
+// until a font for it is added.
+type MsgFont struct {
+	font rl.Font
+	loaded bool  // false when using the built-in font
+}
+
+const (
+	FONT_DIR = "fonts"
+	FONT_RASTER_SIZE = DEFAULT_FONT_SIZE*2  // rasterized larger than drawn to stay sharp when scaled
+	BUILTIN_FONT_LAST_CHAR = 0xFF
+)
+
+var msgFont MsgFont
+
+func fontPaths(lang string) []string {
+	return []string{
+		path.Join(FONT_DIR, lang + ".ttf"),
+		path.Join(FONT_DIR, lang + ".otf"),
+		path.Join(FONT_DIR, "default.ttf"),
+		path.Join(FONT_DIR, "default.otf"),
+	}
+}
+
+// Whether the lines of the table can be drawn, with a font of lang or the built-in font
+func canDrawTable(lang string, table StringTable) bool {
+	for _, name := range fontPaths(lang) {
+		if data, err := readAsset(name); err == nil && len(data) > 0 { return true }
+	}
+	for _, lines := range table {
+		for _, line := range lines {
+			for _, r := range line {
+				if r > BUILTIN_FONT_LAST_CHAR { return false }
+			}
+		}
+	}
+	return true
+}
+
+// Returns the printable ASCII characters and every character of the current
+// and the default string tables, sorted
+func msgCodepoints() []rune {
+	set := map[rune]bool{}
+	for r := rune(32); r < 127; r++ { set[r] = true }
+	for _, table := range []StringTable{strTable, defaultStrTable} {
+		for _, lines := range table {
+			for _, line := range lines {
+				for _, r := range line { set[r] = true }
+			}
+		}
+	}
+
+	codepoints := make([]rune, 0, len(set))
+	for r := range set { codepoints = append(codepoints, r) }
+	sort.Slice(codepoints, func(i, j int) bool { return codepoints[i] < codepoints[j] })
+	return codepoints
+}
+
+// Loads the font for the current language. Needs the window to be initialized.
+func loadMsgFont() {
+	unloadMsgFont()
+	for _, name := range fontPaths(language) {
+		data, err := readAsset(name)
+		if err != nil || len(data) == 0 { continue }
+
+		codepoints := msgCodepoints()
+		font := rl.LoadFontFromMemory(path.Ext(name), data, i32(len(data)), i32(FONT_RASTER_SIZE),
+									  &codepoints[0], i32(len(codepoints)))
+		if font.Texture.ID == 0 {
+			fmt.Println("Failed to load the font", name)
+			continue
+		}
+		rl.SetTextureFilter(font.Texture, rl.FilterBilinear)
+		msgFont = MsgFont{font, true}
+		return
+	}
+
+	if language != DEFAULT_LANGUAGE {
+		fmt.Printf("No font for %q in %s, using the built-in font\n", language, FONT_DIR)
+	}
+	msgFont = MsgFont{rl.GetFontDefault(), false}
+}
+
+func unloadMsgFont() {
+	if msgFont.loaded { rl.UnloadFont(msgFont.font) }
+	msgFont = MsgFont{}
+}
+
+// The spacing between characters. The built-in font needs the spacing
+// rl.DrawText gives it, TTF fonts carry theirs in the glyph advances.
+func msgSpacing(size f32) f32 {
+	if msgFont.loaded { return 0 }
+	return size / 10
+}
+
+func measureMsgText(text string, size f32) Vec2 {
+	return rl.MeasureTextEx(msgFont.font, text, size, msgSpacing(size))
+}
+
+func drawMsgText(text string, pos Vec2, size f32, color rl.Color) {
+	rl.DrawTextEx(msgFont.font, text, pos, size, msgSpacing(size), color)
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"github.com/gen2brain/raylib-go/raylib"
+)
+
+// Gamepads play through the same actions as the keyboard: their buttons are bound
+// by input code(PAD_CODE_BASE + button), so holding one charges a big jump like a key.
+// The buttons of all the connected gamepads count as one, for couch play.
+// The menus move with the D-pad or the left stick, A selects, X resets and Back opens them.
+const (
+	MAX_GAMEPADS = 4
+	NUM_PAD_BUTTONS = GAMEPAD_BUTTON_RIGHT_THUMB + 1
+	STICK_THRESHOLD = 0.5  // of the axis range, for a push of the stick to count
+)
+
+// Xbox names, by position on other gamepads
+var padButtonNames = map[i32]string{
+	GAMEPAD_BUTTON_LEFT_FACE_UP: "PadUp",
+	GAMEPAD_BUTTON_LEFT_FACE_RIGHT: "PadRight",
+	GAMEPAD_BUTTON_LEFT_FACE_DOWN: "PadDown",
+	GAMEPAD_BUTTON_LEFT_FACE_LEFT: "PadLeft",
+	GAMEPAD_BUTTON_RIGHT_FACE_UP: "PadY",
+	GAMEPAD_BUTTON_RIGHT_FACE_RIGHT: "PadB",
+	GAMEPAD_BUTTON_RIGHT_FACE_DOWN: "PadA",
+	GAMEPAD_BUTTON_RIGHT_FACE_LEFT: "PadX",
+	GAMEPAD_BUTTON_LEFT_TRIGGER_1: "PadLB",
+	GAMEPAD_BUTTON_LEFT_TRIGGER_2: "PadLT",
+	GAMEPAD_BUTTON_RIGHT_TRIGGER_1: "PadRB",
+	GAMEPAD_BUTTON_RIGHT_TRIGGER_2: "PadRT",
+	GAMEPAD_BUTTON_MIDDLE_LEFT: "PadBack",
+	GAMEPAD_BUTTON_MIDDLE: "PadHome",
+	GAMEPAD_BUTTON_MIDDLE_RIGHT: "PadStart",
+	GAMEPAD_BUTTON_LEFT_THUMB: "PadLS",
+	GAMEPAD_BUTTON_RIGHT_THUMB: "PadRS",
+}
+
+func padCode(button i32) i32 { return PAD_CODE_BASE + button }
+
+// Adds the buttons of all the connected gamepads to the frame, and the left stick
+// pushed the farthest
+func pollGamepads(frame *InputFrame) {
+	padDown := [NUM_PAD_BUTTONS]bool{}
+	padReleased := [NUM_PAD_BUTTONS]bool{}
+	for pad := i32(0); pad < MAX_GAMEPADS; pad++ {
+		if !rl.IsGamepadAvailable(pad) { continue }
+		for button := i32(1); button < NUM_PAD_BUTTONS; button++ {
+			if rl.IsGamepadButtonDown(pad, button) { padDown[button] = true }
+			if rl.IsGamepadButtonReleased(pad, button) { padReleased[button] = true }
+			if rl.IsGamepadButtonPressed(pad, button) { frame.PadPressed = append(frame.PadPressed, button) }
+		}
+		y := rl.GetGamepadAxisMovement(pad, GAMEPAD_AXIS_LEFT_Y)
+		if y*y > frame.StickY*frame.StickY { frame.StickY = y }
+	}
+	for button := i32(1); button < NUM_PAD_BUTTONS; button++ {
+		if padDown[button] { frame.PadDown = append(frame.PadDown, button) }
+		if padReleased[button] { frame.PadReleased = append(frame.PadReleased, button) }
+	}
+}
+
+// A push of the stick counts once, until it's back within the threshold
+func updateStick(stickY f32) {
+	dir := 0
+	if stickY < -STICK_THRESHOLD { dir = -1 }
+	if stickY > STICK_THRESHOLD { dir = 1 }
+	if dir != input.stickDir {
+		if dir < 0 { input.stickUp = true }
+		if dir > 0 { input.stickDown = true }
+		input.stickDir = dir
+	}
+}
+
+func isPadButtonDown(button i32) bool { return input.padDown[button] }
+func isPadButtonReleased(button i32) bool { return input.padReleased[button] }
+
+func isMenuUp() bool {
+	return isKeyReleased(KEY_UP) || isPadButtonReleased(GAMEPAD_BUTTON_LEFT_FACE_UP) || input.stickUp
+}
+func isMenuDown() bool {
+	return isKeyReleased(KEY_DOWN) || isPadButtonReleased(GAMEPAD_BUTTON_LEFT_FACE_DOWN) || input.stickDown
+}
+func isMenuSelected() bool {
+	return isKeyReleased(KEY_ENTER) || isPadButtonReleased(GAMEPAD_BUTTON_RIGHT_FACE_DOWN)
+}
+func isMenuReset() bool {
+	return isKeyReleased(KEY_BACKSPACE) || isPadButtonReleased(GAMEPAD_BUTTON_RIGHT_FACE_LEFT)
+}
+func isMenuToggled() bool {
+	return isKeyReleased(KEY_F1) || isPadButtonReleased(GAMEPAD_BUTTON_MIDDLE_LEFT)
+}
@@ -0,0 +1,96 @@
+package main
+
+// Input is polled once per rendered frame from an InputSource but consumed by the fixed simulation steps.
+// Releases are latched until a step consumes them, so they are not lost on frames
+// that run no step and are not seen twice on frames that run several.
+type InputState struct {
+	keysDown [MAX_KEY_CODE]bool
+	keysReleased [MAX_KEY_CODE]bool
+	mouseDown [MOUSE_RIGHT + 1]bool
+	mouseReleased [MOUSE_RIGHT + 1]bool
+	mouseX i32
+	mouseY i32
+	keyPressed i32  // the first key pressed since the last step, 0 for none
+	padDown [NUM_PAD_BUTTONS]bool  // on any of the gamepads
+	padReleased [NUM_PAD_BUTTONS]bool
+	padPressed i32  // the first button pressed since the last step, 0 for none
+	stickDir int    // of the left stick: -1 up, 1 down, 0 within STICK_THRESHOLD
+	stickUp bool    // pushed up since the last step
+	stickDown bool
+	quit bool  // asked by the source
+}
+
+// The bindings hold input codes: keys below MAX_KEY_CODE and gamepad buttons
+// from PAD_CODE_BASE on
+const (
+	MAX_KEY_CODE = 512
+	PAD_CODE_BASE = MAX_KEY_CODE
+)
+
+// The fixed keys and the keys of the bindings, rebuilt by watchKeys
+var watchedKeys []i32
+
+var input InputState
+
+// Polls a frame of the source into the input state. Returns the frame time,
+// or false when the source has no more frames.
+func pollInput(src InputSource) (f32, bool) {
+	frame, ok := src.poll()
+	if !ok { return 0, false }
+
+	input.keysDown = [MAX_KEY_CODE]bool{}
+	for _, key := range frame.KeysDown { input.keysDown[key] = true }
+	for _, key := range frame.KeysReleased { input.keysReleased[key] = true }
+	for _, key := range frame.KeysPressed {
+		if input.keyPressed == 0 { input.keyPressed = key }
+	}
+
+	input.mouseDown = [MOUSE_RIGHT + 1]bool{}
+	for _, button := range frame.MouseDown { input.mouseDown[button] = true }
+	for _, button := range frame.MouseReleased { input.mouseReleased[button] = true }
+	input.mouseX, input.mouseY = frame.MouseX, frame.MouseY
+
+	input.padDown = [NUM_PAD_BUTTONS]bool{}
+	for _, button := range frame.PadDown { input.padDown[button] = true }
+	for _, button := range frame.PadReleased { input.padReleased[button] = true }
+	for _, button := range frame.PadPressed {
+		if input.padPressed == 0 { input.padPressed = button }
+	}
+	updateStick(frame.StickY)
+	input.quit = input.quit || frame.Quit
+	return frame.Dt, true
+}
+
+// Called at the end of each simulation step
+func consumeInputEdges() {
+	input.keysReleased = [MAX_KEY_CODE]bool{}
+	input.mouseReleased = [MOUSE_RIGHT + 1]bool{}
+	input.keyPressed = 0
+	input.padReleased = [NUM_PAD_BUTTONS]bool{}
+	input.padPressed = 0
+	input.stickUp, input.stickDown = false, false
+}
+
+func watchKeys() {
+	watchedKeys = append([]i32{}, fixedKeys...)
+	for _, codes := range bindings {
+		for _, code := range codes {
+			if code < MAX_KEY_CODE { watchedKeys = append(watchedKeys, code) }
+		}
+	}
+	// a key unwatched now must not stay down
+	input.keysDown = [MAX_KEY_CODE]bool{}
+}
+
+func isKeyDown(key i32) bool { return input.keysDown[key] }
+func isKeyReleased(key i32) bool { return input.keysReleased[key] }
+func isInputDown(code i32) bool {
+	if code >= PAD_CODE_BASE { return isPadButtonDown(code - PAD_CODE_BASE) }
+	return isKeyDown(code)
+}
+func isInputReleased(code i32) bool {
+	if code >= PAD_CODE_BASE { return isPadButtonReleased(code - PAD_CODE_BASE) }
+	return isKeyReleased(code)
+}
+func isMouseButtonDown(button i32) bool { return input.mouseDown[button] }
+func isMouseButtonReleased(button i32) bool { return input.mouseReleased[button] }
@@ -0,0 +1,313 @@
+package main
+
+import (
+	"github.com/gen2brain/raylib-go/raylib"
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// The raw input of the game comes from an InputSource, polled once per rendered frame:
+// LiveInput reads raylib, RecordedInput plays back a file written by RecordingInput and
+// ScriptedInput plays keys and clicks laid out in a script, so the game loop can run
+// without a player, e.g. for integration tests and replays. A frame carries its frame
+// time, so the same frames always run the same simulation steps.
+type InputSource interface {
+	poll() (InputFrame, bool)  // false once the source has no more frames
+}
+
+// The keys, mouse and gamepad buttons are listed by code, e.g. KeysDown: [65] for A held
+type InputFrame struct {
+	Dt f32 `json:"dt"`  // in seconds
+	KeysDown []i32 `json:"keysDown,omitempty"`
+	KeysReleased []i32 `json:"keysReleased,omitempty"`
+	KeysPressed []i32 `json:"keysPressed,omitempty"`  // in the order they were pressed
+	MouseX i32 `json:"mouseX"`
+	MouseY i32 `json:"mouseY"`
+	MouseDown []i32 `json:"mouseDown,omitempty"`
+	MouseReleased []i32 `json:"mouseReleased,omitempty"`
+	PadDown []i32 `json:"padDown,omitempty"`
+	PadReleased []i32 `json:"padReleased,omitempty"`
+	PadPressed []i32 `json:"padPressed,omitempty"`
+	StickY f32 `json:"stickY,omitempty"`  // of the left stick, in [-1, 1]
+	Quit bool `json:"quit,omitempty"`      // the game quits after the frame
+}
+
+// A recording is a header line followed by a line per frame, all JSON. The header has
+// the seed the boards are shuffled from and the bindings the frames were played with.
+type RecordingHeader struct {
+	Seed i64 `json:"seed"`
+	Bindings map[string][]string `json:"bindings"`
+}
+
+type LiveInput struct{}
+
+type RecordingInput struct {
+	src InputSource
+	file *os.File
+	enc *json.Encoder
+	err error  // the first write error, the recording stops there
+}
+
+type RecordedInput struct {
+	header RecordingHeader
+	frames []InputFrame
+	next int
+}
+
+type ScriptedInput struct {
+	seed i64
+	frames []InputFrame
+	next int
+}
+
+const DEFAULT_SCRIPT_SEED = 1
+
+// Opens the source of the command line: a recording to replay, a script, or the live
+// input, recorded if recordPath is set. Seeds the boards so they're shuffled the same
+// on a replay. A replay also plays with the bindings it was recorded with, the player's
+// are held until restorePlayerBindings.
+func openInputSource(recordPath, replayPath, scriptPath string) (InputSource, error) {
+	numPaths := 0
+	for _, path := range []string{recordPath, replayPath, scriptPath} {
+		if path != "" { numPaths++ }
+	}
+	if numPaths > 1 { return nil, fmt.Errorf("only one of -record, -replay and -script can be used") }
+
+	switch {
+	case replayPath != "":
+		r, err := loadRecordedInput(replayPath)
+		if err != nil { return nil, err }
+		seedBoards(r.header.Seed)
+		holdPlayerBindings()
+		if r.header.Bindings != nil { setBindingNames(r.header.Bindings, replayPath) }
+		return r, nil
+	case scriptPath != "":
+		data, err := os.ReadFile(scriptPath)
+		if err != nil { return nil, err }
+		s, err := parseScript(string(data))
+		if err != nil { return nil, fmt.Errorf("%s: %v", scriptPath, err) }
+		seedBoards(s.seed)
+		holdPlayerBindings()
+		return s, nil
+	case recordPath != "":
+		seed := newSeed()
+		seedBoards(seed)
+		return newRecordingInput(LiveInput{}, recordPath, seed)
+	}
+	return LiveInput{}, nil
+}
+
+func (LiveInput) poll() (InputFrame, bool) {
+	dt := rl.GetFrameTime()
+	if dt > MAX_FRAME_TIME { dt = MAX_FRAME_TIME }
+	frame := InputFrame{Dt: dt, MouseX: rl.GetMouseX(), MouseY: rl.GetMouseY()}
+
+	for _, key := range watchedKeys {
+		if rl.IsKeyDown(key) { frame.KeysDown = append(frame.KeysDown, key) }
+		if rl.IsKeyReleased(key) { frame.KeysReleased = append(frame.KeysReleased, key) }
+	}
+	for key := rl.GetKeyPressed(); key != 0; key = rl.GetKeyPressed() {
+		if key < MAX_KEY_CODE { frame.KeysPressed = append(frame.KeysPressed, key) }
+	}
+	for button := i32(MOUSE_LEFT); button <= MOUSE_RIGHT; button++ {
+		if rl.IsMouseButtonDown(button) { frame.MouseDown = append(frame.MouseDown, button) }
+		if rl.IsMouseButtonReleased(button) { frame.MouseReleased = append(frame.MouseReleased, button) }
+	}
+	pollGamepads(&frame)
+	return frame, true
+}
+
+// Records the frames of src to the file at path along with the seed and the current bindings
+func newRecordingInput(src InputSource, path string, seed i64) (*RecordingInput, error) {
+	file, err := os.Create(path)
+	if err != nil { return nil, err }
+	r := &RecordingInput{src: src, file: file, enc: json.NewEncoder(file)}
+	if err := r.enc.Encode(RecordingHeader{Seed: seed, Bindings: bindingNames()}); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *RecordingInput) poll() (InputFrame, bool) {
+	frame, ok := r.src.poll()
+	if ok && r.err == nil {
+		if r.err = r.enc.Encode(&frame); r.err != nil { fmt.Println("Failed to record the input:", r.err) }
+	}
+	return frame, ok
+}
+
+func (r *RecordingInput) close() error { return r.file.Close() }
+
+func loadRecordedInput(path string) (*RecordedInput, error) {
+	file, err := os.Open(path)
+	if err != nil { return nil, err }
+	defer file.Close()
+
+	r := &RecordedInput{}
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(nil, 1 << 20)
+	for line := 0; scanner.Scan(); line++ {
+		if line == 0 {
+			if err := json.Unmarshal(scanner.Bytes(), &r.header); err != nil {
+				return nil, fmt.Errorf("%s: the header: %v", path, err)
+			}
+			continue
+		}
+		var frame InputFrame
+		if err := json.Unmarshal(scanner.Bytes(), &frame); err != nil {
+			return nil, fmt.Errorf("%s:%d: %v", path, line + 1, err)
+		}
+		if err := frame.validate(); err != nil { return nil, fmt.Errorf("%s:%d: %v", path, line + 1, err) }
+		r.frames = append(r.frames, frame)
+	}
+	if err := scanner.Err(); err != nil { return nil, err }
+	return r, nil
+}
+
+func (r *RecordedInput) poll() (InputFrame, bool) {
+	if r.next >= len(r.frames) { return InputFrame{}, false }
+	r.next++
+	return r.frames[r.next - 1], true
+}
+
+// Checks the codes are within the ranges of the input state
+func (f *InputFrame) validate() error {
+	check := func(what string, codes []i32, max i32) error {
+		for _, code := range codes {
+			if code < 0 || code >= max { return fmt.Errorf("%s %d is out of range", what, code) }
+		}
+		return nil
+	}
+	for _, err := range []error{
+		check("key", f.KeysDown, MAX_KEY_CODE), check("key", f.KeysReleased, MAX_KEY_CODE),
+		check("key", f.KeysPressed, MAX_KEY_CODE),
+		check("mouse button", f.MouseDown, MOUSE_RIGHT + 1), check("mouse button", f.MouseReleased, MOUSE_RIGHT + 1),
+		check("gamepad button", f.PadDown, NUM_PAD_BUTTONS), check("gamepad button", f.PadReleased, NUM_PAD_BUTTONS),
+		check("gamepad button", f.PadPressed, NUM_PAD_BUTTONS),
+	} {
+		if err != nil { return err }
+	}
+	if f.Dt < 0 || f.Dt > MAX_FRAME_TIME { return fmt.Errorf("frame time %v is out of range", f.Dt) }
+	return nil
+}
+
+// A script has a command per line, each frame lasting a simulation step:
+//     seed {n}                the seed of the boards, DEFAULT_SCRIPT_SEED if not given
+//     down {key} / up {key}   holds/releases a key, gamepad button(e.g. PadA) or MouseLeft/MouseRight
+//     move {x} {y}            moves the mouse
+//     wait {steps}            plays the frames with the keys as they are
+//     press {key}             down, wait 1, up, wait 1
+//     click {x} {y}           move, then press MouseLeft
+//     quit                    quits the game, e.g. at the end of a test
+// Keys are named as in bindings.json. Empty lines and the ones starting with # are skipped.
+func parseScript(text string) (*ScriptedInput, error) {
+	s := &ScriptedInput{seed: DEFAULT_SCRIPT_SEED}
+	var state InputFrame  // the held keys, buttons and the mouse position carried across frames
+	wait := func(steps int) {
+		for i := 0; i < steps; i++ {
+			frame := state
+			frame.Dt = SIM_DT
+			frame.KeysDown = append([]i32{}, state.KeysDown...)
+			frame.MouseDown = append([]i32{}, state.MouseDown...)
+			frame.PadDown = append([]i32{}, state.PadDown...)
+			s.frames = append(s.frames, frame)
+			// the edges are on the first frame only
+			state.KeysReleased, state.KeysPressed, state.MouseReleased = nil, nil, nil
+			state.PadReleased, state.PadPressed = nil, nil
+		}
+	}
+
+	for i, line := range strings.Split(text, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") { continue }
+		cmd, args := fields[0], fields[1:]
+		nums, numErr := parseInts(args)
+		var err error
+
+		switch {
+		case cmd == "seed" && len(args) == 1 && numErr == nil:
+			s.seed = i64(nums[0])
+		case (cmd == "down" || cmd == "up" || cmd == "press") && len(args) == 1:
+			if cmd == "press" {
+				if err = state.hold(args[0], true); err != nil { break }
+				wait(1)
+				err = state.hold(args[0], false)
+				wait(1)
+			} else {
+				err = state.hold(args[0], cmd == "down")
+			}
+		case cmd == "move" && len(args) == 2 && numErr == nil:
+			state.MouseX, state.MouseY = i32(nums[0]), i32(nums[1])
+		case cmd == "click" && len(args) == 2 && numErr == nil:
+			state.MouseX, state.MouseY = i32(nums[0]), i32(nums[1])
+			state.hold("MouseLeft", true)
+			wait(1)
+			state.hold("MouseLeft", false)
+			wait(1)
+		case cmd == "wait" && len(args) == 1 && numErr == nil && nums[0] >= 0:
+			wait(nums[0])
+		case cmd == "quit" && len(args) == 0:
+			state.Quit = true
+			wait(1)
+		default:
+			err = fmt.Errorf("can't read %q", strings.TrimSpace(line))
+		}
+		if err != nil { return nil, fmt.Errorf("line %d: %v", i + 1, err) }
+	}
+	// the releases at the end
+	if len(state.KeysReleased) + len(state.MouseReleased) + len(state.PadReleased) > 0 { wait(1) }
+	return s, nil
+}
+
+func parseInts(args []string) ([]int, error) {
+	nums := make([]int, len(args))
+	for i, arg := range args {
+		n, err := strconv.Atoi(arg)
+		if err != nil { return nil, err }
+		nums[i] = n
+	}
+	return nums, nil
+}
+
+// Holds down or releases the key, gamepad button or mouse button of the name
+func (f *InputFrame) hold(name string, down bool) error {
+	var held, released, pressed *[]i32
+	var code i32
+	switch name {
+	case "MouseLeft", "MouseRight":
+		held, released = &f.MouseDown, &f.MouseReleased
+		code = MOUSE_LEFT
+		if name == "MouseRight" { code = MOUSE_RIGHT }
+	default:
+		key, ok := keyByName(name)
+		if !ok { return fmt.Errorf("unknown key %q", name) }
+		held, released, pressed, code = &f.KeysDown, &f.KeysReleased, &f.KeysPressed, key
+		if key >= PAD_CODE_BASE {
+			held, released, pressed, code = &f.PadDown, &f.PadReleased, &f.PadPressed, key - PAD_CODE_BASE
+		}
+	}
+
+	kept := (*held)[:0]
+	for _, c := range *held {
+		if c != code { kept = append(kept, c) }
+	}
+	*held = kept
+	if down {
+		*held = append(*held, code)
+		if pressed != nil { *pressed = append(*pressed, code) }
+	} else {
+		*released = append(*released, code)
+	}
+	return nil
+}
+
+func (s *ScriptedInput) poll() (InputFrame, bool) {
+	if s.next >= len(s.frames) { return InputFrame{}, false }
+	s.next++
+	return s.frames[s.next - 1], true
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func mustParseScript(t *testing.T, text string) *ScriptedInput {
+	t.Helper()
+	s, err := parseScript(text)
+	if err != nil { t.Fatalf("parseScript(%q): %v", text, err) }
+	return s
+}
+
+func TestScriptPressEdgesOnFirstFrame(t *testing.T) {
+	s := mustParseScript(t, "press D\nwait 2")
+	if len(s.frames) != 4 { t.Fatalf("got %d frames, want 4", len(s.frames)) }
+
+	down, up := s.frames[0], s.frames[1]
+	if !reflect.DeepEqual(down.KeysDown, []i32{KEY_D}) || !reflect.DeepEqual(down.KeysPressed, []i32{KEY_D}) ||
+	   len(down.KeysReleased) != 0 {
+		t.Errorf("the down frame is %+v", down)
+	}
+	if len(up.KeysDown) != 0 || len(up.KeysPressed) != 0 || !reflect.DeepEqual(up.KeysReleased, []i32{KEY_D}) {
+		t.Errorf("the up frame is %+v", up)
+	}
+	for i, frame := range s.frames[2:] {
+		if len(frame.KeysDown) + len(frame.KeysPressed) + len(frame.KeysReleased) != 0 {
+			t.Errorf("frame %d after the press has keys: %+v", i + 2, frame)
+		}
+	}
+}
+
+func TestScriptClickEdgesOnFirstFrame(t *testing.T) {
+	s := mustParseScript(t, "click 90 475\nwait 1")
+	if len(s.frames) != 3 { t.Fatalf("got %d frames, want 3", len(s.frames)) }
+
+	for i, frame := range s.frames {
+		if frame.MouseX != 90 || frame.MouseY != 475 { t.Errorf("frame %d: the mouse is at %d, %d", i, frame.MouseX, frame.MouseY) }
+	}
+	if !reflect.DeepEqual(s.frames[0].MouseDown, []i32{MOUSE_LEFT}) || len(s.frames[0].MouseReleased) != 0 {
+		t.Errorf("the down frame is %+v", s.frames[0])
+	}
+	if len(s.frames[1].MouseDown) != 0 || !reflect.DeepEqual(s.frames[1].MouseReleased, []i32{MOUSE_LEFT}) {
+		t.Errorf("the up frame is %+v", s.frames[1])
+	}
+	if len(s.frames[2].MouseDown) + len(s.frames[2].MouseReleased) != 0 {
+		t.Errorf("the frame after the click has buttons: %+v", s.frames[2])
+	}
+}
+
+func TestScriptHeldKeysCarryAcrossFrames(t *testing.T) {
+	s := mustParseScript(t, "down A\nwait 2\ndown PadA\nwait 2\nup A\nwait 1")
+	want := []struct{ keysDown, padDown []i32 }{
+		{[]i32{KEY_A}, nil},
+		{[]i32{KEY_A}, nil},
+		{[]i32{KEY_A}, []i32{GAMEPAD_BUTTON_RIGHT_FACE_DOWN}},
+		{[]i32{KEY_A}, []i32{GAMEPAD_BUTTON_RIGHT_FACE_DOWN}},
+		{nil, []i32{GAMEPAD_BUTTON_RIGHT_FACE_DOWN}},
+	}
+	if len(s.frames) != len(want) { t.Fatalf("got %d frames, want %d", len(s.frames), len(want)) }
+	for i, w := range want {
+		frame := s.frames[i]
+		if len(frame.KeysDown) + len(w.keysDown) > 0 && !reflect.DeepEqual(frame.KeysDown, w.keysDown) {
+			t.Errorf("frame %d: keys down %v, want %v", i, frame.KeysDown, w.keysDown)
+		}
+		if len(frame.PadDown) + len(w.padDown) > 0 && !reflect.DeepEqual(frame.PadDown, w.padDown) {
+			t.Errorf("frame %d: buttons down %v, want %v", i, frame.PadDown, w.padDown)
+		}
+		if frame.Dt != SIM_DT { t.Errorf("frame %d: dt %v, want %v", i, frame.Dt, SIM_DT) }
+	}
+	if !reflect.DeepEqual(s.frames[4].KeysReleased, []i32{KEY_A}) {
+		t.Errorf("frame 4: keys released %v, want A", s.frames[4].KeysReleased)
+	}
+}
+
+func TestScriptUnknownKey(t *testing.T) {
+	_, err := parseScript("wait 1\n\ndown Nope\nwait 1")
+	if err == nil { t.Fatal("no error for an unknown key") }
+	if !strings.Contains(err.Error(), "line 3") || !strings.Contains(err.Error(), "Nope") {
+		t.Errorf("the error %q doesn't name the line and the key", err)
+	}
+}
+
+func TestScriptTrailingRelease(t *testing.T) {
+	s := mustParseScript(t, "down A\nwait 2\nup A")
+	if len(s.frames) != 3 { t.Fatalf("got %d frames, want 3", len(s.frames)) }
+	last := s.frames[2]
+	if len(last.KeysDown) != 0 || !reflect.DeepEqual(last.KeysReleased, []i32{KEY_A}) {
+		t.Errorf("the last frame is %+v, want A released", last)
+	}
+}
+
+func TestRecordingRoundTrip(t *testing.T) {
+	frames := []InputFrame{
+		{Dt: SIM_DT, KeysDown: []i32{KEY_A}, KeysPressed: []i32{KEY_A}, MouseX: 10, MouseY: 20},
+		{Dt: SIM_DT, KeysReleased: []i32{KEY_A}, MouseX: 12, MouseY: 20, MouseDown: []i32{MOUSE_LEFT}},
+		{Dt: 0.02, MouseReleased: []i32{MOUSE_LEFT}, PadDown: []i32{GAMEPAD_BUTTON_LEFT_FACE_UP},
+		 PadPressed: []i32{GAMEPAD_BUTTON_LEFT_FACE_UP}, StickY: -0.75},
+		{Dt: SIM_DT, PadReleased: []i32{GAMEPAD_BUTTON_LEFT_FACE_UP}, Quit: true},
+	}
+	path := filepath.Join(t.TempDir(), "input.jsonl")
+
+	rec, err := newRecordingInput(&RecordedInput{frames: frames}, path, 42)
+	if err != nil { t.Fatal(err) }
+	for _, ok := rec.poll(); ok; _, ok = rec.poll() {}
+	if rec.err != nil { t.Fatal(rec.err) }
+	if err := rec.close(); err != nil { t.Fatal(err) }
+
+	loaded, err := loadRecordedInput(path)
+	if err != nil { t.Fatal(err) }
+	if loaded.header.Seed != 42 { t.Errorf("seed %d, want 42", loaded.header.Seed) }
+	if !reflect.DeepEqual(loaded.header.Bindings, bindingNames()) {
+		t.Errorf("bindings %v, want %v", loaded.header.Bindings, bindingNames())
+	}
+	if !reflect.DeepEqual(loaded.frames, frames) { t.Errorf("frames\n%+v\nwant\n%+v", loaded.frames, frames) }
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"math"
+)
+
+// A jump is a parabolic arc from start to dest evaluated by time. The apex of the arc
+// is peak pixels above the higher of the two ends, and the arc ends exactly on dest
+// when elapsed reaches duration, so there is no landing to detect.
+//
+// With u = elapsed/duration in [0, 1], the height of the arc is
+//     y(u) = start.Y + (dest.Y - start.Y)*u - 4*curve*u*(1 - u)
+// and curve is solved from peak in newJump. It is defined for any start, dest,
+// duration > 0 and peak >= 0.
+type Jump struct {
+	start Vec2
+	dest Vec2
+	peak f32
+	duration f32  // in seconds
+	elapsed f32   // in seconds
+	curve f32
+	onLand func(anim *Animal)  // called once the animal is on dest, before the jump is cleared
+}
+
+func newJump(start, dest Vec2, duration, peak f32) Jump {
+	assert(duration > 0, "jump duration is not positive")
+	if peak < 0 { peak = 0 }
+
+	// K: the height of the apex above start, where y'(u) = 0 and y(u) = start.Y - K.
+	// Solving (4c - dy)^2 = 16cK for c gives the root with the apex in [0, 1] below.
+	dy := f64(dest.Y - start.Y)
+	k := f64(peak)
+	if dy < 0 { k -= dy }
+	curve := (dy + 2*k + 2*math.Sqrt(math.Max(k*(k + dy), 0))) / 4
+
+	return Jump{start: start, dest: dest, peak: peak, duration: duration, curve: f32(curve)}
+}
+
+func (j *Jump) active() bool { return j.duration > 0 }
+
+func (j *Jump) progress() f32 {
+	if j.elapsed >= j.duration { return 1 }
+	return j.elapsed / j.duration
+}
+
+func (j *Jump) posAt(u f32) Vec2 {
+	pos := Vec2Lerp(j.start, j.dest, u)
+	pos.Y -= 4 * j.curve * u * (1 - u)
+	return pos
+}
+
+// Returns the progress where the arc is at its apex
+func (j *Jump) apex() f32 {
+	if j.curve <= 0 { return 0 }
+	u := (4*j.curve - (j.dest.Y - j.start.Y)) / (8*j.curve)
+	if u < 0 { return 0 }
+	if u > 1 { return 1 }
+	return u
+}
+
+// Returns how high the apex is above y
+func (j *Jump) height(y f32) f32 {
+	return y - j.posAt(j.apex()).Y
+}
+
+// Returns the vertical velocity on landing in pixels/frame, positive when falling
+func (j *Jump) landingVeloc() f32 {
+	return (j.dest.Y - j.start.Y + 4*j.curve) / (j.duration * FPS)
+}
+
+// The animal grows by JUMP_SCALE_INC_RATE every frame while ascending
+// and shrinks back to 1 by the time it lands.
+func (j *Jump) scaleAt(u f32) f32 {
+	apex := j.apex()
+	maxScale := 1 + JUMP_SCALE_INC_RATE * apex * j.duration * FPS
+	if u < apex { return lerp(1, maxScale, u/apex) }
+	if apex >= 1 { return 1 }
+	return lerp(maxScale, 1, (u - apex)/(1 - apex))
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+)
+
+// String tables of the in-game messages. Each language has a JSON file in the assets' LANG_DIR,
+// named by its code(en.json, es.json...), that maps message IDs to their lines.
+// IDs missing from a table fall back to DEFAULT_LANGUAGE.
+type StringTable map[string][]string
+
+const (
+	LANG_DIR = "lang"
+	DEFAULT_LANGUAGE = "en"
+)
+
+var language string
+var strTable StringTable
+var defaultStrTable StringTable
+
+// The language of the settings if set, otherwise the one of the environment locale
+func detectLanguage() string {
+	if settings.Language != "" { return settings.Language }
+
+	for _, env := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		locale := os.Getenv(env)
+		if locale == "" || locale == "C" || locale == "POSIX" { continue }
+		// e.g. es_ES.UTF-8 -> es
+		lang, _, _ := strings.Cut(locale, "_")
+		lang, _, _ = strings.Cut(lang, ".")
+		return strings.ToLower(lang)
+	}
+	return DEFAULT_LANGUAGE
+}
+
+func loadStringTable(lang string) (StringTable, error) {
+	data, err := readAsset(path.Join(LANG_DIR, lang + ".json"))
+	if err != nil { return nil, err }
+
+	table := StringTable{}
+	if err := json.Unmarshal(data, &table); err != nil {
+		return nil, fmt.Errorf("%s.json: %v", lang, err)
+	}
+	return table, nil
+}
+
+// Loads the table of lang, falling back to DEFAULT_LANGUAGE if it can't be loaded
+func setLanguage(lang string) {
+	var err error
+	defaultStrTable, err = loadStringTable(DEFAULT_LANGUAGE)
+	if err != nil { fmt.Println("Failed to load the default string table:", err) }
+
+	language, strTable = DEFAULT_LANGUAGE, defaultStrTable
+	if lang == DEFAULT_LANGUAGE { return }
+
+	table, err := loadStringTable(lang)
+	if err != nil {
+		fmt.Printf("Language %q is not available, using %q: %v\n", lang, DEFAULT_LANGUAGE, err)
+		return
+	}
+	if !canDrawTable(lang, table) {
+		fmt.Printf("No font for %q in %s, using %q\n", lang, FONT_DIR, DEFAULT_LANGUAGE)
+		return
+	}
+	language, strTable = lang, table
+}
+
+// Returns the lines of the message id in the current language
+func tr(id string) []string {
+	if lines, ok := strTable[id]; ok { return lines }
+	if lines, ok := defaultStrTable[id]; ok { return lines }
+	if DEBUG { fmt.Printf("No string for the message id %q\n", id) }
+	return []string{id}
+}
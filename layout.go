@@ -0,0 +1,104 @@
+package main
+
+import (
+	"github.com/gen2brain/raylib-go/raylib"
+	"strings"
+	"unicode"
+)
+
+// Text layout of the message board. The lines of a message are word wrapped to the
+// width of the message area, shrunk until they fit its height, and centered in it.
+type TextLayout struct {
+	lines []string
+	pos []Vec2  // top left of each line
+	size f32
+}
+
+const (
+	MSG_AREA_WIDTH  = WINDOW_WIDTH - 2*MARGIN_WIDTH
+	MSG_AREA_HEIGHT = DEFAULT_FONT_SIZE*3
+	MSG_CENTER_Y    = MSG_POS_Y + DEFAULT_FONT_SIZE/2
+	MIN_FONT_SIZE   = DEFAULT_FONT_SIZE*0.5
+	LINE_SPACING    = 1.1  // line height in font size
+)
+
+func layoutMsg(lines []string) TextLayout {
+	return layoutText(lines, Vec2{WINDOW_WIDTH/2, MSG_CENTER_Y},
+					  MSG_AREA_WIDTH, MSG_AREA_HEIGHT, DEFAULT_FONT_SIZE)
+}
+
+// Lays out the paragraphs centered on center within maxWidth and maxHeight,
+// starting from the font size and shrinking it down to MIN_FONT_SIZE if needed
+func layoutText(paragraphs []string, center Vec2, maxWidth, maxHeight, size f32) TextLayout {
+	var lines []string
+	for ; ; size *= 0.9 {
+		lines = lines[:0]
+		for _, p := range paragraphs { lines = append(lines, wrapText(p, size, maxWidth)...) }
+		if size*0.9 < MIN_FONT_SIZE || fits(lines, size, maxWidth, maxHeight) { break }
+	}
+
+	lineHeight := size * LINE_SPACING
+	top := center.Y - textHeight(len(lines), size)/2
+	layout := TextLayout{lines: lines, pos: make([]Vec2, len(lines)), size: size}
+	for i, line := range lines {
+		width := measureMsgText(line, size).X
+		layout.pos[i] = Vec2{center.X - width/2, top + lineHeight*f32(i)}
+	}
+	return layout
+}
+
+func textHeight(numLines int, size f32) f32 {
+	return size*LINE_SPACING*f32(numLines - 1) + size
+}
+
+func fits(lines []string, size, maxWidth, maxHeight f32) bool {
+	if textHeight(len(lines), size) > maxHeight { return false }
+	for _, line := range lines {
+		if measureMsgText(line, size).X > maxWidth { return false }
+	}
+	return true
+}
+
+// Breaks text into lines no wider than maxWidth, between words or between
+// CJK characters, which aren't separated by spaces. A word wider than
+// maxWidth is left on its own line.
+func wrapText(text string, size, maxWidth f32) []string {
+	var lines []string
+	line := ""
+	for _, word := range splitWords(text) {
+		candidate := line + word
+		if line != "" && measureMsgText(strings.TrimRight(candidate, " "), size).X > maxWidth {
+			lines = append(lines, strings.TrimRight(line, " "))
+			candidate = strings.TrimLeft(word, " ")
+		}
+		line = candidate
+	}
+	if line = strings.TrimRight(line, " "); line != "" || len(lines) == 0 { lines = append(lines, line) }
+	return lines
+}
+
+// Splits text into the units a line can break after: words with their trailing space,
+// and CJK characters one by one
+func splitWords(text string) []string {
+	var words []string
+	word := ""
+	for _, r := range text {
+		if isCJK(r) {
+			if word != "" { words = append(words, word) }
+			words, word = append(words, string(r)), ""
+			continue
+		}
+		word += string(r)
+		if r == ' ' { words, word = append(words, word), "" }
+	}
+	if word != "" { words = append(words, word) }
+	return words
+}
+
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana)
+}
+
+func (l *TextLayout) draw(color rl.Color) {
+	for i, line := range l.lines { drawMsgText(line, l.pos[i], l.size, color) }
+}
@@ -2,10 +2,13 @@ package main
 
 import (
 	"github.com/gen2brain/raylib-go/raylib"
+	"context"
+	"flag"
 	"fmt"
+	"os"
 	"crypto/rand"
 	"math/big"
-	"time"
+	mrand "math/rand"
 	"reflect"
 )
 
@@ -28,13 +31,19 @@ func Vec2Div(v Vec2, f f32) Vec2 { return Vec2{v.X / f, v.Y / f} }
 func Vec2Neg(v Vec2) Vec2 { return Vec2{-v.X, -v.Y} }
 func Vec2LenSq(v Vec2) f32 { return v.X * v.X + v.Y * v.Y }
 func Vec2DistSq(v1, v2 Vec2) f32 { return Vec2LenSq(Vec2Sub(v1, v2)) }
+func Vec2Lerp(v1, v2 Vec2, t f32) Vec2 { return Vec2{lerp(v1.X, v2.X, t), lerp(v1.Y, v2.Y, t)} }
+func lerp(a, b, t f32) f32 { return a + (b - a) * t }
 
 // Assert
 func assert(b bool, msg string) { if !b { panic("Assert failed: " + msg + "!\n") } }
 
 // Constants
 const (
+	// FPS is the rate of the fixed simulation step, the frame counts below are in steps.
+	// Rendering runs at the display rate and interpolates between the last two steps.
 	FPS               = 60
+	SIM_DT            = 1.0/FPS
+	MAX_FRAME_TIME    = 0.25
 	WINDOW_WIDTH      = 560
 	WINDOW_HEIGHT     = 800
 	UPPER_LAND_HEIGHT = WINDOW_WIDTH
@@ -54,13 +63,19 @@ const (
 	MIN_ANIM_HEIGHT	  =	WINDOW_HEIGHT/160
 	MIN_JUMP_HEIGHT   = MIN_ANIM_HEIGHT * 3
 	JUMP_SCALE_INC_RATE = 0.075
+	SQUASH_DURATION   = 0.33
+	RECOVER_DURATION  = 0.12
+	SPRING_DURATION   = 0.3   // of the spring back from a squash
+	CHARGE_DURATION   = 0.6
+	TITLE_FALL_DURATION = 0.42
+	MSG_BLINK_FRAMES  = 128
+	HINT_MAX_NODES    = 20000  // far more than a whole board takes, so a hint never stalls a step
 	MAX_DUST_DURATION = FPS/3
     FRONT_ROW_Y       = MARGIN_HEIGHT + (NUM_ROW - 1)*ROW_HEIGHT + ROW_HEIGHT/2
 	RESQUE_SPOT_X     = MARGIN_WIDTH + (WINDOW_WIDTH - 2 * MARGIN_WIDTH) / 2 
 	RESQUE_SPOT_Y     = (UPPER_LAND_HEIGHT + 7 * MARGIN_HEIGHT) + 
 						 (WINDOW_HEIGHT - (UPPER_LAND_HEIGHT + 7 * MARGIN_HEIGHT)) / 2 
 	DEFAULT_FONT_SIZE = MARGIN_WIDTH*1.2
-	MAX_MSG_LEN       = DEFAULT_FONT_SIZE*2
 	MSG_POS_Y         = UPPER_LAND_HEIGHT - MARGIN_HEIGHT
 	BOARD_SIZE        = NUM_ROW * NUM_COL
 	FRONT_ROW_BASEINDEX = BOARD_SIZE - NUM_COL
@@ -78,13 +93,61 @@ const (
 	KEY_F = 70
 	KEY_G = 71
 	KEY_Q = 81
+	KEY_T = 84
+	KEY_M = 77
+	KEY_MINUS = 45
+	KEY_EQUAL = 61
 	KEY_SPACE = 32
+	KEY_H = 72
+	KEY_P = 80
+	KEY_U = 85
+	KEY_Z = 90
+	KEY_ZERO = 48
+	KEY_NINE = 57
+	KEY_APOSTROPHE = 39
+	KEY_COMMA = 44
+	KEY_PERIOD = 46
+	KEY_SLASH = 47
+	KEY_SEMICOLON = 59
+	KEY_LEFT_BRACKET = 91
+	KEY_RIGHT_BRACKET = 93
+	KEY_ESCAPE = 256
+	KEY_ENTER = 257
+	KEY_TAB = 258
+	KEY_BACKSPACE = 259
+	KEY_F1 = 290
+	KEY_F12 = 301
+	KEY_LEFT_SHIFT = 340
+	KEY_LEFT_CONTROL = 341
+	KEY_LEFT_ALT = 342
+	KEY_RIGHT_SHIFT = 344
+	KEY_RIGHT_CONTROL = 345
+	KEY_RIGHT_ALT = 346
 	MOUSE_LEFT = 0
 	MOUSE_RIGHT = 1
 	KEY_RIGHT = 262
 	KEY_LEFT = 263
 	KEY_DOWN = 264
 	KEY_UP = 265
+	GAMEPAD_BUTTON_LEFT_FACE_UP = 1     // D-pad
+	GAMEPAD_BUTTON_LEFT_FACE_RIGHT = 2
+	GAMEPAD_BUTTON_LEFT_FACE_DOWN = 3
+	GAMEPAD_BUTTON_LEFT_FACE_LEFT = 4
+	GAMEPAD_BUTTON_RIGHT_FACE_UP = 5    // Xbox Y, PS Triangle
+	GAMEPAD_BUTTON_RIGHT_FACE_RIGHT = 6 // Xbox B, PS Circle
+	GAMEPAD_BUTTON_RIGHT_FACE_DOWN = 7  // Xbox A, PS Cross
+	GAMEPAD_BUTTON_RIGHT_FACE_LEFT = 8  // Xbox X, PS Square
+	GAMEPAD_BUTTON_LEFT_TRIGGER_1 = 9
+	GAMEPAD_BUTTON_LEFT_TRIGGER_2 = 10
+	GAMEPAD_BUTTON_RIGHT_TRIGGER_1 = 11
+	GAMEPAD_BUTTON_RIGHT_TRIGGER_2 = 12
+	GAMEPAD_BUTTON_MIDDLE_LEFT = 13     // Back, Select
+	GAMEPAD_BUTTON_MIDDLE = 14
+	GAMEPAD_BUTTON_MIDDLE_RIGHT = 15    // Start
+	GAMEPAD_BUTTON_LEFT_THUMB = 16
+	GAMEPAD_BUTTON_RIGHT_THUMB = 17
+	GAMEPAD_AXIS_LEFT_X = 0
+	GAMEPAD_AXIS_LEFT_Y = 1
 )
 
 // GameMode Enums
@@ -122,13 +185,16 @@ type Sounds struct {
 
 // Message board system
 type Message struct {
-	l1 string
-	l2 string
+	id string
+	lines []string
+	layout TextLayout
 	duration int
-	frames int
+	minFrames int  // shown at least for minFrames before a message of the same or higher priority
+	frames int     // since shown
 	displayed bool
-	alpha u8
+	alpha f32
 	gameMode GameMode
+	priority MsgPriority
 }
 
 type Scripts struct {
@@ -137,52 +203,42 @@ type Scripts struct {
 
 // TITLE GameMode states
 type TitleState struct {
-	destForOpening [3]Vec2
-    titleFrame int
-	animToDrop int
-	titleDropFrame int
-	firstCompressFrame int
-	lastAnimDropFrame int
-	titlePressFrame int
-	lastAnimJumpFrame int
-	secondCompressFrame int
-	fallOutFrame int
+	destForOpening [NUM_TITLE_ANIMS]Vec2
+	timeline TimelinePlayer
 	titleMessageShown bool
-	sceneEnd bool
 }
 
-// accel, veloc and press in pixels/frame.
+// prevPos and prevHeight are the state of the previous step for the render interpolation.
 type TitleLogo struct {
 	pos Vec2
+	prevPos Vec2
 	dest Vec2
-	accel Vec2
-	veloc Vec2
 	height f32
-	press f32
+	prevHeight f32
 }
 
 type Animal struct {
 	pos Vec2
+	prevPos Vec2
 	dest Vec2
-	accel Vec2
-	veloc Vec2
 	scale f32
+	prevScale f32
 	height f32
-	press f32
-	scaleDecRate f32
+	prevHeight f32
+	jump Jump
+	bigJump bool
+	charging bool  // set by processKeyDown on every step it's held for a big jump
 	dustDuration u8
-	totalJumpFrames u8
-	ascFrames u8
-	currJumpFrame u8
 	animType u8
 }
 
 // Global Variables
 var textures Textures  
+var animalsAtlas Atlas
 var sounds Sounds  
 var gameMode GameMode
-var msg Message
 var scripts Scripts
+var boardRand *mrand.Rand  // shuffles the boards when seeded, e.g. for a replay
 
 // For DEBUG
 func printbd (board *[BOARD_SIZE]*Animal) {
@@ -214,63 +270,26 @@ func setTitleLogo(title *TitleLogo) {
 	title.pos.Y = -TITLE_HEIGHT
 	title.height = TITLE_HEIGHT
 	title.dest = Vec2{title.pos.X, TITLE_LANDING_Y} 
+	title.prevPos, title.prevHeight = title.pos, title.height
 }
 
-func updateTitle(title *TitleLogo) bool {
-	isUpdated := true
-
-	if title.press > 0 {
-		isUpdated = false
-		title.height -= title.press 	
-		if title.height < MIN_TITLE_HEIGHT {
-			title.height = MIN_TITLE_HEIGHT
-			title.press = -1
-		} else {
-			title.press /= 1.75
-			if title.press < 0.0001 {
-				title.press = -1
-			}
-		}
-	}
-	if title.press < 0 {
-		isUpdated = false
-		title.height -= title.press 	
-		if title.height >= TITLE_HEIGHT {
-			title.height = TITLE_HEIGHT
-			title.press = 0
-		} else {
-			title.press *= 1.75
-		}
-	}
+// Drops the title logo onto its dest, bouncing like a ball. It first lands after
+// TITLE_FALL_DURATION, where the intro squashes it.
+func dropTitle(title *TitleLogo) {
+	tweenTo(&title.pos.Y, title.dest.Y, TITLE_FALL_DURATION/BOUNCE_FIRST_LANDING, easeOutBounce)
+}
 
-	// Update Pos and Veloc if it's moving or has accel
-	if ((title.veloc != Vec2{}) || (title.accel != Vec2{})) && title.press == 0 {
-		isUpdated = false
-		pos := title.pos
-		veloc := title.veloc
-		title.pos = Vec2Add(pos, veloc)
-		title.veloc = Vec2Add(title.veloc, title.accel)
-	     
-		// take care of landing
-		if title.veloc.Y > 0 && Vec2DistSq(title.pos, title.dest) < Vec2LenSq(title.veloc) / 2 {
-		    title.press = title.veloc.Y/2
-		    title.pos = title.dest
-			if title.veloc.Y >= 2 {
-		        title.veloc = Vec2{0, -title.veloc.Y/2} 
-			} else {
-				title.press = 0
-				title.veloc = Vec2{}
-				title.accel = Vec2{}
-			}
-		}
-	}
-	return isUpdated
+func updateTitle(title *TitleLogo) bool {
+	return !isTweening(&title.pos.Y) && !isTweening(&title.height)
 }
 
-func drawTitle(title *TitleLogo) {
+// interp: how far the render is between the previous and the current step, in [0, 1)
+func drawTitle(title *TitleLogo, interp f32) {
+	pos := Vec2Lerp(title.prevPos, title.pos, interp)
+	height := lerp(title.prevHeight, title.height, interp)
     srcRect := rl.Rectangle{0, 0, TITLE_WIDTH , TITLE_HEIGHT}
-	desPos := Vec2{title.pos.X, title.pos.Y + TITLE_HEIGHT - title.height}
-	desRect := rl.Rectangle{desPos.X, desPos.Y, TITLE_WIDTH, title.height}
+	desPos := Vec2{pos.X, pos.Y + TITLE_HEIGHT - height}
+	desRect := rl.Rectangle{desPos.X, desPos.Y, TITLE_WIDTH, height}
 	rl.DrawTexturePro(textures.TitleTexture, srcRect, desRect, Vec2{}, 0, rl.RayWhite)
 }
 
@@ -282,8 +301,10 @@ func setAnimals(animals *[BOARD_SIZE]Animal) {
 		for col := 0; col < NUM_COL; col++ {
 			boardIndex := row * NUM_COL + col
 			animals[boardIndex].height = ANIM_SIZE 
+			animals[boardIndex].prevHeight = ANIM_SIZE 
 			animals[boardIndex].animType = colorBit | kind
 			animals[boardIndex].scale = 1
+			animals[boardIndex].prevScale = 1
 			kind <<= 1
 		}
 		kind = 1
@@ -291,11 +312,25 @@ func setAnimals(animals *[BOARD_SIZE]Animal) {
 	}
 }
 
+// Returns a random index in [0, n), from boardRand if the boards are seeded
+func randIndex(n i64) int {
+	if boardRand != nil { return boardRand.Intn(int(n)) }
+	randomIndex, _ := rand.Int(rand.Reader, big.NewInt(n))
+	return int(randomIndex.Uint64())
+}
+
+// Shuffles the boards from now on in the same order for the same seed
+func seedBoards(seed i64) { boardRand = mrand.New(mrand.NewSource(seed)) }
+
+func newSeed() i64 {
+	seed, _ := rand.Int(rand.Reader, big.NewInt(1 << 62))
+	return seed.Int64()
+}
+
 func shuffleBoard(board *[BOARD_SIZE]*Animal, frontRowPos *[NUM_COL]Vec2) {
 	for i := 0; i < BOARD_SIZE - 2; i++ {
 		rangeToLastIndex := i64(BOARD_SIZE - 1 - i)
-		randomIndex, _ := rand.Int(rand.Reader, big.NewInt(rangeToLastIndex))
-        indexToSwap :=  i + 1 + int(randomIndex.Uint64())
+        indexToSwap :=  i + 1 + randIndex(rangeToLastIndex)
         board[i], board[indexToSwap] = board[indexToSwap], board[i]
     }
 	board[0], board[BOARD_SIZE - 1] = board[BOARD_SIZE - 1], board[0]
@@ -307,6 +342,7 @@ func shuffleBoard(board *[BOARD_SIZE]*Animal, frontRowPos *[NUM_COL]Vec2) {
             posX := f32(MARGIN_WIDTH + (col * COL_WIDTH) + (COL_WIDTH / 2))
             board[boardIndex].dest = Vec2{posX, posY}
 			board[boardIndex].pos = Vec2{posX, posY - f32(4*ROW_HEIGHT)}
+			board[boardIndex].prevPos = board[boardIndex].pos
         }
     }
 	for i := 0; i < NUM_COL; i++ {
@@ -333,16 +369,19 @@ func findResquables(board *[BOARD_SIZE]*Animal, mostRecentRescuedType u8,
     return nextAnimNum
 }
 
-func resqueAt(board, resqued *[BOARD_SIZE]*Animal, resqueIndex, numAnimalLeft int) {
+func resqueAt(board, resqued *[BOARD_SIZE]*Animal, resqueIndex, numAnimalLeft int, onLand func(anim *Animal)) {
     i := resqueIndex			  
     anim := board[i]
     assert(anim.animType != 0, "animal to be resque has type 0")
 
-    ascFrames := 2.5 * ANIM_SIZE/anim.height
-    totalFrames := FPS/3 + ascFrames
-    if ascFrames < FPS/15  { ascFrames, totalFrames = FPS/15, FPS/3 }
-    jumpAnimal(anim, Vec2{RESQUE_SPOT_X, RESQUE_SPOT_Y}, totalFrames, ascFrames)
+    // the more the animal is pressed, the higher and the longer it jumps
+    pressRatio := ANIM_SIZE/anim.height
+    jumpAnimal(anim, Vec2{RESQUE_SPOT_X, RESQUE_SPOT_Y}, 1.0/3 + pressRatio/24, 
+               12 + pressRatio*pressRatio*10)
+	anim.jump.onLand = onLand
     resqued[BOARD_SIZE - numAnimalLeft] = anim
+	emit(Event{kind: EVENT_RESCUED, anim: anim, from: anim.pos, to: anim.dest})
+	if anim.bigJump { emit(Event{kind: EVENT_BIG_JUMP_STARTED, anim: anim, from: anim.pos, to: anim.dest}) }
 
 	// Advance the row where the selected animal is at 
     for i >= 0 && board[i] != nil {
@@ -352,88 +391,55 @@ func resqueAt(board, resqued *[BOARD_SIZE]*Animal, resqueIndex, numAnimalLeft in
         } else {
             board[i] = board[i - NUM_COL]
             anim := board[i]
-            jumpAnimal(anim, Vec2{anim.pos.X, anim.pos.Y + ROW_HEIGHT}, FPS/3, FPS/10)
+            jumpAnimal(anim, Vec2{anim.pos.X, anim.pos.Y + ROW_HEIGHT}, 0.33, 35)
 		}
         i -= NUM_COL
     }
 }
 
-// totalFrames: the total duration of the jump in frames.
-// ascFrames: the duration of animal moving upward ing in frames.
-// if dest.Y is greater than anim.pos.Y, ascFrames has to be less than totalFrames/2
-// and dest.Y is less than anim.pos.Y, ascFrames has to be greater than totalFrmaes/2
-// TotalFrames CANNOT be exactly 2*ascFrames otherwise divided by 0 occurs
-func jumpAnimal(anim *Animal, dest Vec2, totalFrames, ascFrames f32) {
+// duration: the duration of the jump in seconds.
+// peak: the height of the apex in pixels above the higher of anim.pos and dest.
+// The animal lands exactly on dest after duration, see Jump.
+func jumpAnimal(anim *Animal, dest Vec2, duration, peak f32) {
 
-	// desFrames: frames left at the its original position while desending to dest
-	desFrames := totalFrames - ascFrames
-	
-	//veloc = -accel * ascFrames (accel is positive)
-	//leastAlt = anim.pos.Y + 0.5*ascFrames*veloc (veloc is negative)
-	//dest.Y = leastAlt + 0.5*accel*(desFrame)^2
-	var diff f32
-	if anim.pos.Y == dest.Y {
-		diff = -ANIM_SIZE
-	} else {
-		diff = dest.Y - anim.pos.Y
-	}
-	anim.accel = Vec2{0, 2*diff/(desFrames*desFrames - ascFrames*ascFrames) }
-	anim.veloc = Vec2{(dest.X-anim.pos.X)/totalFrames, -anim.accel.Y*ascFrames}
+	anim.jump = newJump(anim.pos, dest, duration, peak)
+	anim.jump.onLand = landAnimal
+	cancelTweens(&anim.pos.X)
+	cancelTweens(&anim.pos.Y)
 	anim.dest = dest
-	anim.totalJumpFrames = u8(totalFrames)
-	anim.ascFrames = u8(ascFrames)
-	anim.currJumpFrame = 0
+	anim.bigJump = false
 	
-	if anim.height < ANIM_SIZE { anim.press = -anim.height/10}
+	if anim.height < ANIM_SIZE { tweenTo(&anim.height, ANIM_SIZE, RECOVER_DURATION, easeInQuad) }
     
-    if anim.dest.X == RESQUE_SPOT_X && anim.dest.Y == RESQUE_SPOT_Y {
-        if gameMode == GAME_PLAY && anim.height <= MIN_JUMP_HEIGHT { 
-            rl.PlaySound(sounds.BigJump)
-        } else {
-            if gameMode != GAME_CLEAR { rl.PlaySound(sounds.Jump) }
-        }
+    if anim.dest.X == RESQUE_SPOT_X && anim.dest.Y == RESQUE_SPOT_Y &&
+       gameMode == GAME_PLAY && anim.height <= MIN_JUMP_HEIGHT { 
+        anim.bigJump = true
     }
 }
 
-func drawAnimal(anim *Animal) {
-	colorBitfield := anim.animType >> NUM_KIND
-	kindBitfield := anim.animType & 0b1111
-	colorOffset := NUM_COLOR - 1 - findFirst1Bit(colorBitfield)
-	kindOffset := NUM_KIND - 1 - findFirst1Bit(kindBitfield)
-    srcRect := rl.Rectangle{f32(kindOffset) * ANIM_SIZE, f32(colorOffset) * ANIM_SIZE,
-                         ANIM_SIZE, ANIM_SIZE}
+// interp: how far the render is between the previous and the current step, in [0, 1)
+func drawAnimal(anim *Animal, interp f32) {
+	srcRect := animalsAtlas.rect(anim.animType)
 	
-	if anim.totalJumpFrames > 0 {
-		if anim.currJumpFrame <= anim.ascFrames {
-			anim.scale += JUMP_SCALE_INC_RATE
-			if anim.currJumpFrame == anim.ascFrames { 
-				anim.scaleDecRate = (anim.scale - 1) / 
-									f32(anim.totalJumpFrames - anim.ascFrames)
-			}
-		} else {
-			anim.scale -= anim.scaleDecRate 
-			if anim.scale < 1 { anim.scale = 1 }
-		}
-	}
-	
-	sc := anim.scale
-	animOrigin := Vec2Sub(anim.pos, Vec2{sc*ANIM_SIZE / 2, sc*ANIM_SIZE / 2})
-	desPos := Vec2{animOrigin.X, animOrigin.Y + sc*ANIM_SIZE - sc*anim.height}
-	desRect := rl.Rectangle{desPos.X, desPos.Y, sc*ANIM_SIZE, sc*anim.height}
+	pos := Vec2Lerp(anim.prevPos, anim.pos, interp)
+	height := lerp(anim.prevHeight, anim.height, interp)
+	sc := lerp(anim.prevScale, anim.scale, interp)
+	animOrigin := Vec2Sub(pos, Vec2{sc*ANIM_SIZE / 2, sc*ANIM_SIZE / 2})
+	desPos := Vec2{animOrigin.X, animOrigin.Y + sc*ANIM_SIZE - sc*height}
+	desRect := rl.Rectangle{desPos.X, desPos.Y, sc*ANIM_SIZE, sc*height}
 
 	rl.DrawTexturePro(textures.AnimalsTexture, srcRect, desRect, Vec2{}, 0, rl.RayWhite)
 	
 	// Draw dust
 	if anim.dustDuration != 0 { 
 		srcRect := rl.Rectangle{0, 0, DUST_IMAGE_WIDTH, DUST_IMAGE_HEIGHT}
-		desRect := rl.Rectangle{anim.pos.X - ANIM_SIZE*0.7, anim.pos.Y + ANIM_SIZE*0.4, 
+		desRect := rl.Rectangle{pos.X - ANIM_SIZE*0.7, pos.Y + ANIM_SIZE*0.4, 
 								ANIM_SIZE*0.5, ANIM_SIZE*0.15} 
 		rl.DrawTexturePro(textures.DustTexture, srcRect, desRect, Vec2{}, 0, rl.RayWhite)
 
-		desRect = rl.Rectangle{anim.pos.X + ANIM_SIZE*0.2, anim.pos.Y + ANIM_SIZE*0.4,  
+		desRect = rl.Rectangle{pos.X + ANIM_SIZE*0.2, pos.Y + ANIM_SIZE*0.4,  
 								ANIM_SIZE*0.5, ANIM_SIZE*0.15} 
 		rl.DrawTexturePro(textures.DustTexture, srcRect, desRect, Vec2{}, 0, rl.RayWhite)
-		anim.dustDuration -= 1
 	}
 }
 
@@ -441,8 +447,8 @@ func drawAnimal(anim *Animal) {
 func isAnimRectClicked(animal *Animal) bool {
 	if animal == nil { return false}
 
-	mouseX := f32(rl.GetMouseX())
-	mouseY := f32(rl.GetMouseY())
+	mouseX := f32(input.mouseX)
+	mouseY := f32(input.mouseY)
 	animPosX := animal.pos.X
 	animPosY := animal.pos.Y
 	halfLength := ANIM_SIZE / 2
@@ -456,37 +462,100 @@ func isAnimRectClicked(animal *Animal) bool {
 		   mouseY >= animPosY - halfLength && mouseY <= animPosY + halfLength   
 }
 
-func loadAssets() {
-    titleImage := rl.LoadImage("assets/textures/title.png")
-    groundImage := rl.LoadImage("assets/textures/background.png")
-    animalsImage := rl.LoadImage("assets/textures/animals.png")
-    dustImage := rl.LoadImage("assets/textures/dust.png")
+// Loads the textures of the theme in the settings and the sounds, replacing the ones
+// that fail with placeholders and synthesized sounds. Returns an error only if a texture can't be made at all.
+func loadAssets() error {
+	var report AssetReport
+	defer report.print()
+
+	themeID = settings.Theme
+	if themeID == "" { themeID = DEFAULT_THEME }
+	theme, err := loadTheme(themeID)
+	if err != nil {
+		fmt.Printf("Failed to load the theme %q, using the default: %v\n", themeID, err)
+		theme, themeID = defaultTheme, DEFAULT_THEME
+	}
+	textures, animalsAtlas, err = loadTextures(theme, &report)
+	if err != nil { return err }
+
+	sounds.TitleJump = loadSound(&report, "sounds/titlejump.mp3", synthTitleJump)
+	sounds.TitleLand = loadSound(&report, "sounds/titleland.mp3", synthTitleLand)
+	sounds.Start = loadSound(&report, "sounds/start.mp3", synthStart)
+	sounds.Jump = loadSound(&report, "sounds/jump.wav", synthJump)
+	sounds.BigJump = loadSound(&report, "sounds/bigjump.mp3", synthBigJump)
+	sounds.Land = loadSound(&report, "sounds/land.mp3", synthLand)
+	sounds.BigLand = loadSound(&report, "sounds/bigland.mp3", synthBigLand)
+	sounds.Success = loadSound(&report, "sounds/success.mp3", synthSuccess)
+	sounds.Fail = loadSound(&report, "sounds/fail.mp3", synthFail)
+	sounds.Yay = loadSound(&report, "sounds/yay.mp3", synthYay)
+	loadMusic(&report)
+	return nil
+}
+
+func loadTextures(theme Theme, report *AssetReport) (Textures, Atlas, error) {
+	titleImage := loadImage(report, theme.Title, genTitleImage)
+	groundImage := loadImage(report, theme.Background, genGroundImage)
+	animalsImage, atlas := loadAnimals(report, theme.Animals)
+	dustImage := loadImage(report, theme.Dust, genDustImage)
     
 	rl.ImageResize(titleImage, TITLE_WIDTH, TITLE_HEIGHT)
 	rl.ImageResize(groundImage, WINDOW_WIDTH, WINDOW_HEIGHT)
-    rl.ImageResize(animalsImage, i32(ANIM_SIZE * NUM_COL), i32(ANIM_SIZE * NUM_ROW))
 
-    textures.TitleTexture = rl.LoadTextureFromImage(titleImage)
-    textures.GroundTexture = rl.LoadTextureFromImage(groundImage)
-    textures.AnimalsTexture = rl.LoadTextureFromImage(animalsImage)
-    textures.DustTexture = rl.LoadTextureFromImage(dustImage)
+	var tx Textures
+    tx.TitleTexture = rl.LoadTextureFromImage(titleImage)
+    tx.GroundTexture = rl.LoadTextureFromImage(groundImage)
+    tx.AnimalsTexture = rl.LoadTextureFromImage(animalsImage)
+    tx.DustTexture = rl.LoadTextureFromImage(dustImage)
+	// the sprites are drawn smaller than the sheet's resolution
+	rl.GenTextureMipmaps(&tx.AnimalsTexture)
+	rl.SetTextureFilter(tx.AnimalsTexture, rl.FilterTrilinear)
     
 	rl.UnloadImage(titleImage)
 	rl.UnloadImage(groundImage)
     rl.UnloadImage(animalsImage)
     rl.UnloadImage(dustImage)
 
-	sounds.TitleJump = rl.LoadSound("assets/sounds/titlejump.mp3")
-	sounds.TitleLand = rl.LoadSound("assets/sounds/titleland.mp3")
-	sounds.Start = rl.LoadSound("assets/sounds/start.mp3")
-	sounds.Jump = rl.LoadSound("assets/sounds/jump.wav")
-	sounds.BigJump = rl.LoadSound("assets/sounds/bigjump.mp3")
-	sounds.Land = rl.LoadSound("assets/sounds/land.mp3")
-	sounds.BigLand = rl.LoadSound("assets/sounds/bigland.mp3")
-	sounds.Success = rl.LoadSound("assets/sounds/success.mp3")
-	sounds.Fail = rl.LoadSound("assets/sounds/fail.mp3")
-	sounds.Yay = rl.LoadSound("assets/sounds/yay.mp3")
-	sounds.Fail = rl.LoadSound("assets/sounds/fail.mp3")
+	// the dust is only an effect, the game can go on without it
+	if tx.TitleTexture.ID == 0 { return tx, atlas, fmt.Errorf("the title texture can't be created") }
+	if tx.GroundTexture.ID == 0 { return tx, atlas, fmt.Errorf("the background texture can't be created") }
+	if tx.AnimalsTexture.ID == 0 { return tx, atlas, fmt.Errorf("the animals texture can't be created") }
+	return tx, atlas, nil
+}
+
+// Loads the sheet of the atlas manifest, or a placeholder sheet with its grid atlas
+func loadAnimals(report *AssetReport, name string) (*rl.Image, Atlas) {
+	atlas, err := loadAtlas(name)
+	if err != nil {
+		report.fail(name, err, "using placeholders")
+		return genAnimalsImage(), gridAtlas(ANIM_SIZE, ANIM_SIZE)
+	}
+
+	image, err := loadImageAsset(atlas.Image)
+	if err == nil { err = atlas.fits(image) }
+	if err != nil {
+		rl.UnloadImage(image)
+		report.fail(atlas.Image, err, "using placeholders")
+		return genAnimalsImage(), gridAtlas(ANIM_SIZE, ANIM_SIZE)
+	}
+	return image, atlas
+}
+
+func loadImage(report *AssetReport, name string, placeholder func() *rl.Image) *rl.Image {
+	image, err := loadImageAsset(name)
+	if err == nil { return image }
+	rl.UnloadImage(image)
+	report.fail(name, err, "using a placeholder")
+	return placeholder()
+}
+
+// Loads the sound asset, or synthesizes it if it fails or the audio theme is retro
+func loadSound(report *AssetReport, name string, synth Synth) rl.Sound {
+	if settings.AudioTheme == RETRO_AUDIO_THEME { return synthSound(synth) }
+	sound, err := loadSoundAsset(name)
+	if err == nil { return sound }
+	rl.UnloadSound(sound)
+	report.fail(name, err, "synthesized")
+	return synthSound(synth)
 }
 
 func unloadSounds() {
@@ -519,7 +588,7 @@ func moveResquedToBoard(board, resqued *[BOARD_SIZE]*Animal, boardIndex, resqued
 		if nextAnimToPush != nil {
 			jumpAnimal(nextAnimToPush, 
 			           Vec2{nextAnimToPush.pos.X, nextAnimToPush.pos.Y - ROW_HEIGHT}, 
-					   20, 12) 
+					   0.15, 10) 
 			animToPush = nextAnimToPush
 			i -= NUM_COL
 		} else { 
@@ -539,7 +608,7 @@ func scatterResqued(board, resqued *[BOARD_SIZE]*Animal, maxIndexToScatter int,
 
 	for i := 0; i < NUM_COL; i++ {
 		if lastRowEmptyIndices[i] {
-			jumpAnimal(resqued[indexToMoveToBoard], frontRowPos[i], 24, 16)
+			jumpAnimal(resqued[indexToMoveToBoard], frontRowPos[i], 0.4, 115)
 			frontRowIndexToJump := BOARD_SIZE - NUM_COL + i
 			moveResquedToBoard(board, resqued, frontRowIndexToJump, indexToMoveToBoard,
 		                       numAnimalLeft, resquedChanged)
@@ -555,111 +624,107 @@ func scatterResqued(board, resqued *[BOARD_SIZE]*Animal, maxIndexToScatter int,
 	resqued[maxIndexToScatter + 1] = nil
 }
 
-func updateAnimState(animals *[BOARD_SIZE]Animal, board, resqued *[BOARD_SIZE]*Animal, 
-                     frontRowPos *[NUM_COL]Vec2, numAnimalLeft *int, 
-					 resquedChanged, bigJumpMade *bool, bigJumpLeft *int, lastMsgShown bool) bool {
+func updateAnimState(animals *[BOARD_SIZE]Animal) bool {
 	isAllUpdated := true
 
 	for i := range animals {
 		anim := &animals[i]
-		// Update Press and Height 
-		if anim.press > 0 {
-			anim.height -= anim.press 	
-			if anim.height < MIN_ANIM_HEIGHT {
-				anim.height = MIN_ANIM_HEIGHT
-			}
-			anim.press /= 2
-			if anim.press < 0.0001 {
-				anim.press = -1
-			}
-		}
-		if anim.press < 0 {
-			if anim.height - anim.press >= ANIM_SIZE {
-				anim.height = ANIM_SIZE
-				anim.press = 0
-			} else {
-				anim.height -= anim.press
-				anim.press *= 2
-			}
+		if anim.dustDuration != 0 { anim.dustDuration -= 1 }
+
+		// Spring back when it's not held for a big jump anymore
+		if anim.charging {
+			anim.charging = false
+		} else if to, ok := tweenTarget(&anim.height); ok && to == MIN_JUMP_HEIGHT ||
+				  !ok && anim.height == MIN_JUMP_HEIGHT {
+			tweenTo(&anim.height, ANIM_SIZE, RECOVER_DURATION, easeInQuad)
 		}
 
-		// Update Pos and Veloc if it's moving or has accel
-		if !(anim.veloc == Vec2{}) || !(anim.accel == Vec2{}) {
+		// Move along the jump arc and land on its dest when it's done
+		if anim.jump.active() {
 			isAllUpdated = false
-			pos := anim.pos
-			veloc := anim.veloc
-			anim.pos = Vec2Add(pos, veloc)
-			anim.veloc = Vec2Add(anim.veloc, anim.accel)
-			if anim.totalJumpFrames > 0 { anim.currJumpFrame++ }
-
-			// Take care of landing
-			if Vec2DistSq(anim.pos, anim.dest) < Vec2LenSq(anim.veloc) / 2 {
-                if gameMode == GAME_PLAY && 
-                   anim.ascFrames > anim.totalJumpFrames/2 && anim.dest.Y == FRONT_ROW_Y {
-                    rl.PlaySound(sounds.Yay)
-                }
-                if anim.veloc.Y <= FPS {
-				    if anim.veloc.Y > FPS/2 { 
-                        anim.press = anim.veloc.Y / 3 
-                        if gameMode == GAME_PLAY { rl.PlaySound(sounds.Land) }
-                    }
-                } else {
-                    anim.dustDuration = MAX_DUST_DURATION
-                    if anim.dest.X == RESQUE_SPOT_X && anim.dest.Y == RESQUE_SPOT_Y {
-                        rl.PlaySound(sounds.BigLand)
-                    } else {
-                        rl.PlaySound(sounds.Land) 
-                    }
-                }
-				// if the landing animal is the last resqued(the one crossing the bridge)
-				lastResquedIndex := BOARD_SIZE - 1 - *numAnimalLeft
-				if gameMode == GAME_PLAY && lastResquedIndex > 0 && 
-				   anim == resqued[lastResquedIndex] {
-					// when a big jump is made, send previously resqued animals back to the land
-				    if anim.veloc.Y > FPS && *bigJumpLeft > 0 {
-                        *bigJumpMade = true
-                        scatterResqued(board, resqued, lastResquedIndex - 1, frontRowPos,
-                                       numAnimalLeft, resquedChanged)
-                        *bigJumpLeft -= 1
-                        if lastMsgShown && *bigJumpLeft == 1 { setMsg(GAME_PLAY, 3) }
-                        if *bigJumpLeft == 0 { setMsg(GAME_PLAY, 4) }
-				    } else {
-					    // For regular jumps, compress and move the previously resqued sideway
-						prevAnimIndex := lastResquedIndex - 1
-						prevAnim := resqued[prevAnimIndex]
-						prevAnim.press = ANIM_SIZE
-						pushFactor := f32(prevAnimIndex/2 + 1)
-						if prevAnimIndex % 2 == 0 {
-							prevAnim.dest = Vec2Sub(prevAnim.pos, 
-													Vec2{pushFactor * ANIM_SIZE * 0.25, 0})
-							prevAnim.accel = Vec2{pushFactor * ANIM_SIZE*0.075, 0}
-							prevAnim.veloc = Vec2{pushFactor * -ANIM_SIZE*0.15, 0}
-						} else {
-							prevAnim.dest = Vec2Add(prevAnim.pos, 
-													Vec2{pushFactor * ANIM_SIZE * 0.25, 0})
-							prevAnim.accel = Vec2{pushFactor * -ANIM_SIZE*0.075, 0}
-							prevAnim.veloc = Vec2{pushFactor * ANIM_SIZE*0.15, 0}
-						}
-					}
-				}
+			anim.jump.elapsed += SIM_DT
+			u := anim.jump.progress()
+			anim.pos = anim.jump.posAt(u)
+			anim.scale = anim.jump.scaleAt(u)
 
+			if u >= 1 {
+				if anim.jump.onLand != nil { anim.jump.onLand(anim) }
 				anim.pos = anim.dest
-				anim.veloc, anim.accel = Vec2{}, Vec2{}
 				anim.scale = 1
-				anim.scaleDecRate = 0
-				anim.totalJumpFrames = 0
-				anim.ascFrames = 0
-				anim.currJumpFrame = 0
+				anim.bigJump = false
+				anim.jump = Jump{}
 			}
+		} else if isTweening(&anim.pos.X) || isTweening(&anim.pos.Y) {
+			// sliding, e.g. when pushed sideway
+			isAllUpdated = false
 		}
 	}
 
 	return isAllUpdated
 }
 
+// The onLand of the jumps of jumpAnimal: squashes the animal on a hard landing
+// and raises dust on a harder one
+func landAnimal(anim *Animal) {
+	landVeloc := anim.jump.landingVeloc()
+	emit(Event{kind: EVENT_LANDED, anim: anim, from: anim.jump.start, to: anim.jump.dest,
+			   veloc: landVeloc})
+	if landVeloc <= FPS {
+		if landVeloc > FPS/2 { 
+			squash(&anim.height, ANIM_SIZE, landVeloc*2/3, MIN_ANIM_HEIGHT)
+		}
+	} else {
+		anim.dustDuration = MAX_DUST_DURATION
+	}
+}
+
+// Lands the rescued animal on the rescue spot. After a big jump, the previously rescued
+// are sent back to the land, otherwise the one on the spot is pushed sideway.
+func landResqued(anim *Animal, board, resqued *[BOARD_SIZE]*Animal, frontRowPos *[NUM_COL]Vec2,
+				 numAnimalLeft *int, resquedChanged, bigJumpMade *bool, bigJumpLeft *int) {
+	landAnimal(anim)
+
+	// if the landing animal is the last resqued(the one crossing the bridge)
+	lastResquedIndex := BOARD_SIZE - 1 - *numAnimalLeft
+	if gameMode != GAME_PLAY || lastResquedIndex <= 0 || anim != resqued[lastResquedIndex] { return }
+
+	if anim.bigJump && *bigJumpLeft > 0 {
+		*bigJumpMade = true
+		scatterResqued(board, resqued, lastResquedIndex - 1, frontRowPos, numAnimalLeft, resquedChanged)
+		*bigJumpLeft -= 1
+		emit(Event{kind: EVENT_SCATTERED, anim: anim, bigJumpsLeft: *bigJumpLeft})
+	} else {
+		// For regular jumps, compress and move the previously resqued sideway
+		prevAnimIndex := lastResquedIndex - 1
+		prevAnim := resqued[prevAnimIndex]
+		squash(&prevAnim.height, ANIM_SIZE, 2*ANIM_SIZE, MIN_ANIM_HEIGHT)
+		pushFactor := f32(prevAnimIndex/2 + 1)
+		if prevAnimIndex % 2 == 0 {
+			prevAnim.dest = Vec2Sub(prevAnim.pos, Vec2{pushFactor * ANIM_SIZE * 0.25, 0})
+		} else {
+			prevAnim.dest = Vec2Add(prevAnim.pos, Vec2{pushFactor * ANIM_SIZE * 0.25, 0})
+		}
+		tweenTo(&prevAnim.pos.X, prevAnim.dest.X, 0.05, easeOutQuad)
+	}
+}
+
+// Keeps the state of the last step so the render can interpolate toward the current one
+func savePrevState(animals *[BOARD_SIZE]Animal, title *TitleLogo) {
+	for i := range animals {
+		anim := &animals[i]
+		anim.prevPos, anim.prevHeight, anim.prevScale = anim.pos, anim.height, anim.scale
+	}
+	title.prevPos, title.prevHeight = title.pos, title.height
+}
+
 func resetState(animals *[BOARD_SIZE]Animal, board, resqued *[BOARD_SIZE]*Animal,
 			    frontRowPos *[NUM_COL]Vec2) {
 	
+	for i := range animals {
+		cancelTweens(&animals[i].pos.X)
+		cancelTweens(&animals[i].pos.Y)
+		cancelTweens(&animals[i].height)
+	}
 	*animals = [BOARD_SIZE]Animal{}
 	setAnimals(animals)
 
@@ -671,46 +736,67 @@ func resetState(animals *[BOARD_SIZE]Animal, board, resqued *[BOARD_SIZE]*Animal
 	shuffleBoard(board, frontRowPos)
 
 	*resqued = [BOARD_SIZE]*Animal{}
+	cursor = Cursor{}
 }
 
+// Charges the animal for a big jump by pressing it down to MIN_JUMP_HEIGHT while the key is down
 func processKeyDown(anim *Animal) {
-	anim.press = anim.height/20
-	if anim.height < MIN_JUMP_HEIGHT { anim.height = MIN_JUMP_HEIGHT } 
+	anim.charging = true
+	if to, ok := tweenTarget(&anim.height); ok && to == MIN_JUMP_HEIGHT { return }
+	if anim.height > MIN_JUMP_HEIGHT {
+		tweenTo(&anim.height, MIN_JUMP_HEIGHT, CHARGE_DURATION, easeOutQuad)
+	}
 }
 
-func addMsg(scr *Scripts, duration int, gameMode GameMode, l1, l2 string) {
-	assert(gameMode > 0, "GameMode is less than 1 in the setNextMsg function")
-	for len(l1) < MAX_MSG_LEN {
-		if f32(len(l1)) < f32(MAX_MSG_LEN*0.6) {
-		    l1 = "     " + l1 + " "
-		} else {
-		    l1 = " " + l1 + " "
-	    }
+// Hops the animal of the first move of a solution from the current state, and tells
+// if it should be a big jump or if there is no solution. The search is bounded by
+// HINT_MAX_NODES rather than by time, so a replay gets the same hints.
+func showHint(board, resqued *[BOARD_SIZE]*Animal, numAnimalLeft, bigJumpLeft int) {
+	st := solverState{board: boardTypes(board), numResqued: BOARD_SIZE - numAnimalLeft,
+					  bigJumpLeft: bigJumpLeft}
+	for i := 0; i < st.numResqued; i++ { st.resqued[i] = resqued[i].animType }
+
+	result, err := solveState(context.Background(), st, HINT_MAX_NODES)
+	if err != nil {
+		if DEBUG { fmt.Println("No hint:", err) }
+		postMsg(GAME_PLAY, 7)
+		return
 	}
-	for l2 != "" && len(l2) < MAX_MSG_LEN {
-		if f32(len(l2)) < f32(MAX_MSG_LEN*0.6) {
-		    l2 = "     " + l2 + " "
-		} else {
-		    l2 = " " + l2 + " "
-	    }
+	if !result.solvable {
+		postMsg(GAME_PLAY, 6)
+		return
 	}
-	scr.msgs[gameMode-1] = append(scr.msgs[gameMode-1], Message{l1, l2, duration, 1, false, 0, gameMode})
+
+	move := result.moves[0]
+	anim := board[FRONT_ROW_BASEINDEX + move.col]
+	jumpAnimal(anim, anim.pos, 0.25, ANIM_SIZE/3)
+	if move.big { postMsg(GAME_PLAY, 5) }
+}
+
+// Whether any input that charges and rescues the front row is held
+func isRescueHeld() bool {
+	return isActionDown(ACTION_RESCUE1) || isActionDown(ACTION_RESCUE2) || isActionDown(ACTION_RESCUE3) ||
+		   isActionDown(ACTION_RESCUE4) || isActionDown(ACTION_CURSOR_RESCUE) || isMouseButtonDown(MOUSE_LEFT)
 }
 
-func setMsg(gameMode GameMode, msgNum int) {
+// Lets go of the charge being held without rescuing: the animal springs back
+// to its full height as it's not held anymore, and a dragged one goes back to its place.
+// Nothing is charged or rescued while chargeCanceled, until the rescue inputs are released.
+func cancelCharge(chargeCanceled *bool) {
+	if DEBUG { fmt.Println("Charge canceled!") }
+	*chargeCanceled = true
+	drag.cancel()
+}
+
+// Adds the message of the id, in the current language, to the scripts of the gameMode
+func addMsg(scr *Scripts, duration int, gameMode GameMode, priority MsgPriority, id string) {
 	assert(gameMode > 0, "GameMode is less than 1 in the setNextMsg function")
-	if msgNum >= len(scripts.msgs[gameMode-1]) {
-		if DEBUG {
-		    fmt.Printf("msgNum %d is greater than the msg len for game gameMode %d!\n", msgNum, gameMode)
-	    }
-		msg = Message{}
-	} else {
-	    msg = scripts.msgs[gameMode-1][msgNum]
-	}
+	scr.msgs[gameMode-1] = append(scr.msgs[gameMode-1], Message{id: id, lines: tr(id),
+		duration: duration, minFrames: MIN_MSG_FRAMES, gameMode: gameMode, priority: priority})
 }
 
-func setTitleAnims(titleAnims *[3]*Animal, tstate *TitleState) {
-	for i := 0; i < 3; i++ {
+func setTitleAnims(titleAnims *[NUM_TITLE_ANIMS]*Animal, tstate *TitleState) {
+	for i := 0; i < NUM_TITLE_ANIMS; i++ {
 	    tstate.destForOpening[i] = titleAnims[i].dest 
 	}
 
@@ -721,6 +807,26 @@ func setTitleAnims(titleAnims *[3]*Animal, tstate *TitleState) {
 
 func main() {
 
+	solveSeeds := flag.String("solve", "", "solve the boards of a seed range(e.g. 1-1000) and exit")
+	solveWorkers := flag.Int("workers", 0, "number of solver workers, 0 for one per CPU core")
+	renderFPS := flag.Int("fps", 0, "frame rate cap of the rendering, 0 for the monitor refresh rate")
+	introPath := flag.String("intro", "", "timeline file of the title instead of the built-in one")
+	flag.StringVar(&assetOverrideDir, "assets", "", "directory with asset files replacing the built-in ones")
+	recordPath := flag.String("record", "", "record the input to a file to play back with -replay")
+	replayPath := flag.String("replay", "", "play back the input recorded to a file, then go on live")
+	scriptPath := flag.String("script", "", "play the input of a script file, then go on live")
+	flag.Parse()
+	if *solveSeeds != "" { os.Exit(runSolve(*solveSeeds, *solveWorkers)) }
+
+	loadSettings()
+	loadBindings()
+	source, err := openInputSource(*recordPath, *replayPath, *scriptPath)
+	if err != nil {
+		fmt.Println("Failed to open the input:", err)
+		os.Exit(1)
+	}
+	if recording, ok := source.(*RecordingInput); ok { defer recording.close() }
+
 	title := TitleLogo{}
 	setTitleLogo(&title)
 
@@ -730,25 +836,27 @@ func main() {
 	frontRowPos := [NUM_COL]Vec2{}
 	resetState(&animals, &board, &resqued, &frontRowPos)
 
-	tstate := TitleState{}
+	titleTimeline, err := loadTimeline(TITLE_TIMELINE, readAsset)
+	if *introPath != "" { titleTimeline, err = loadTimeline(*introPath, os.ReadFile) }
+	if err != nil { fmt.Println("Failed to load the title timeline:", err) }
+	tstate := TitleState{timeline: newTimelinePlayer(titleTimeline)}
 	firstRow := BOARD_SIZE - NUM_COL
-	titleAnims :=[3]*Animal{board[firstRow], board[firstRow+2], board[firstRow+1]}
+	titleAnims :=[NUM_TITLE_ANIMS]*Animal{board[firstRow], board[firstRow+2], board[firstRow+1]}
 	setTitleAnims(&titleAnims, &tstate) 
 
-	addMsg(&scripts, INDEFINITE, TITLE, "Press Space or Click anywhere to play", "")
-	addMsg(&scripts, INDEFINITE, GAME_PLAY, "Pick one from the front row carefully", 
-	       "The following has to be same kind or color")
-	addMsg(&scripts, INDEFINITE, GAME_PLAY, "Press and hold for BIG JUMP", "")
-	addMsg(&scripts, FPS*5, GAME_PLAY, "Yay! Do BIG JUMP before getting stuck", 
-	       "You have one more BIG JUMP")
-	addMsg(&scripts, FPS*5, GAME_PLAY, "Only one more BIG JUMP left!", 
-           "Please, use it wisely...")
-	addMsg(&scripts, FPS*5, GAME_PLAY, "Ugh.. No more BIG JUMP!!!", "")
-	addMsg(&scripts, INDEFINITE, GAME_CLEAR, "All animals has crossed!", 
-	       "Press G or click the last one to play again!")
-	addMsg(&scripts, INDEFINITE, GAME_OVER, "Oops, it's a dead-end!", 
-	       "Press G or click the last one to try again!")
-	msg.gameMode = TITLE
+	setLanguage(detectLanguage())
+	addMsg(&scripts, INDEFINITE, TITLE, MSG_INFO, "title.start")
+	addMsg(&scripts, INDEFINITE, GAME_PLAY, MSG_TUTORIAL, "play.pick")
+	addMsg(&scripts, INDEFINITE, GAME_PLAY, MSG_TUTORIAL, "play.hold")
+	addMsg(&scripts, FPS*5, GAME_PLAY, MSG_WARNING, "play.bigJumpOneMore")
+	addMsg(&scripts, FPS*5, GAME_PLAY, MSG_WARNING, "play.bigJumpLast")
+	addMsg(&scripts, FPS*5, GAME_PLAY, MSG_WARNING, "play.noBigJump")
+	addMsg(&scripts, FPS*3, GAME_PLAY, MSG_INFO, "play.hintBigJump")
+	addMsg(&scripts, FPS*5, GAME_PLAY, MSG_WARNING, "play.noWay")
+	addMsg(&scripts, FPS*3, GAME_PLAY, MSG_INFO, "play.noHint")
+	addMsg(&scripts, INDEFINITE, GAME_CLEAR, MSG_RESULT, "clear.allCrossed")
+	addMsg(&scripts, INDEFINITE, GAME_OVER, MSG_RESULT, "over.deadEnd")
+	msgQueue.gameMode = TITLE
 
 	numAnimalLeft := BOARD_SIZE
     resquableIndex := [NUM_COL]int{}
@@ -761,6 +869,10 @@ func main() {
     bigJumpLeft := TOTAL_BIG_JUMP
 	willReplay := false
 	firstMoveMade, bigJumpMade, lastMsgShown := false, false, false
+	accumulator := f32(0)
+	pauseFrames := 0  // steps to hold the simulation for, e.g. before replaying
+	paused := false
+	chargeCanceled := false
 
 	resquedChanged := true
 	mostRecentResqueType := u8(0xFF)  // initially, all front row animals can be resqued.
@@ -771,314 +883,343 @@ func main() {
     }
 
     rl.InitWindow(WINDOW_WIDTH, WINDOW_HEIGHT, "Animal Logic")
-    rl.SetTargetFPS(FPS)
+	if *renderFPS == 0 { *renderFPS = rl.GetMonitorRefreshRate(rl.GetCurrentMonitor()) }
+	if *renderFPS <= 0 { *renderFPS = FPS }
+    rl.SetTargetFPS(i32(*renderFPS))
 	rl.InitAudioDevice();
-	loadAssets();
+	if err := loadAssets(); err != nil {
+		fmt.Println("Failed to load the assets:", err)
+		rl.CloseAudioDevice()
+		rl.CloseWindow()
+		os.Exit(1)
+	}
+	loadMsgFont()
+	subscribeAudio()
+	subscribeMessages(&lastMsgShown)
+	landOnSpot := func(anim *Animal) {
+		landResqued(anim, &board, &resqued, &frontRowPos, &numAnimalLeft, &resquedChanged, &bigJumpMade, &bigJumpLeft)
+	}
     
 	// Game loop
     for !isQuitting && !rl.WindowShouldClose() {
 
-		if msg.frames > 0 {
-			if msg.duration != INDEFINITE && msg.frames > msg.duration && msg.alpha < 2 { 
-				msg = Message{}
-			} else { 
-				msg.frames++
+		frameTime, ok := pollInput(source)
+		if !ok {
+			// the replay or the script is over, the player takes over
+			source = LiveInput{}
+			restorePlayerBindings()
+			frameTime, _ = pollInput(source)
+		}
+		accumulator += frameTime
+		if input.quit { isQuitting = true }
+
+		// Simulate in fixed steps regardless of the display rate
+		for accumulator >= SIM_DT {
+			accumulator -= SIM_DT
+			savePrevState(&animals, &title)
+			if pauseFrames > 0 {
+				pauseFrames--
+				consumeInputEdges()
+				continue
 			}
-	    }
 
-	    switch gameMode {
-
-			// title mode
-			case TITLE:
-	
-			if tstate.animToDrop < 2 && (tstate.titleFrame == 20 || tstate.titleFrame == 40) { 
-				anim := titleAnims[tstate.animToDrop]
-				jumpAnimal(anim, anim.dest, 20, 7)
-				tstate.animToDrop++
-				if tstate.animToDrop == 2 { 
-					tstate.titleDropFrame = tstate.titleFrame + FPS 
-				}
-			}
-			if tstate.animToDrop == 2 && tstate.titleFrame == tstate.titleDropFrame { 
-				title.accel = Vec2{0, 1}
-				tstate.firstCompressFrame = tstate.titleFrame + 0.4*FPS
-			}
-			if tstate.animToDrop == 2 && tstate.titleFrame == tstate.firstCompressFrame {
-                rl.PlaySound(sounds.TitleLand)
-				anim0, anim1 := titleAnims[0], titleAnims[1] 
-				anim0.press = ANIM_SIZE*0.75
-				anim0.veloc = Vec2{15, 0}
-				anim0.accel = Vec2{-1.5, 0}
-				anim0.dest = Vec2{75 + anim0.pos.X, anim0.pos.Y}
-				anim1.dest = Vec2{anim1.pos.X - 125, anim1.pos.Y}
-				jumpAnimal(anim1, anim1.dest, 14, 8)
-				tstate.lastAnimDropFrame = tstate.titleFrame + 3*FPS
-			}
-			if tstate.animToDrop == 2 && tstate.titleFrame == tstate.lastAnimDropFrame {
-				anim := titleAnims[tstate.animToDrop]
-				jumpAnimal(anim, anim.dest, 20, 6)
-				tstate.titlePressFrame = tstate.titleFrame + 0.3*FPS	
+			if isMenuToggled() { rebindScreen.toggle() }
+			if rebindScreen.open {
+				rebindScreen.update()
+				consumeInputEdges()
+				continue
 			}
-			if tstate.animToDrop == 2 && tstate.titleFrame == tstate.titlePressFrame {
-                rl.PlaySound(sounds.TitleJump)
-				title.press = 12.5
-				tstate.lastAnimJumpFrame = tstate.titleFrame + 0.2*FPS
-			}
-			if tstate.animToDrop == 2 && tstate.titleFrame == tstate.lastAnimJumpFrame {
-				anim := titleAnims[2]
-				jumpAnimal(anim, titleAnims[1].pos, 24, 11)
-				tstate.animToDrop++
-				tstate.secondCompressFrame = tstate.titleFrame + 24
-			}
-			if tstate.animToDrop == 3 && tstate.titleFrame == tstate.secondCompressFrame {
-				anim := titleAnims[1]
-				anim0 := titleAnims[0]
-				anim.press = ANIM_SIZE*0.75
-				anim.veloc = Vec2{30, 0}
-				anim.accel = Vec2{-1, 0}
-				anim.dest = Vec2{anim0.pos.X, anim.pos.Y}
-				tstate.fallOutFrame = tstate.titleFrame + 24
-			}
-			if tstate.animToDrop == 3 && tstate.titleFrame == tstate.fallOutFrame {
-				anim := titleAnims[0]
-				jumpAnimal(anim, Vec2{RESQUE_SPOT_X, RESQUE_SPOT_Y}, 26, 8)
-				tstate.sceneEnd = true
+			if isActionReleased(ACTION_PAUSE) { paused = !paused }
+			if paused {
+				consumeInputEdges()
+				continue
 			}
+
+			msgQueue.update()
+			if isKeyReleased(KEY_T) { cycleTheme() }
+			if isKeyReleased(KEY_M) { toggleMute() }
+			if isKeyReleased(KEY_MINUS) { changeVolume(-VOLUME_STEP) }
+			if isKeyReleased(KEY_EQUAL) { changeVolume(VOLUME_STEP) }
+
+		    switch gameMode {
+
+				// title mode
+				case TITLE:
+	
+				tstate.timeline.update(SIM_DT, &title, &titleAnims)
 			
-			isTitleUpdated = updateTitle(&title)
+				isTitleUpdated = updateTitle(&title)
 			
-			if tstate.sceneEnd && isTitleUpdated && isAllAnimUpdated {
-				if !tstate.titleMessageShown {
-					setMsg(gameMode, 0)
-					tstate.titleMessageShown = true
-				}
-			    if rl.IsKeyReleased(KEY_SPACE) || rl.IsMouseButtonReleased(MOUSE_LEFT) {
-					if DEBUG { fmt.Println("Space released!") }
-                    rl.PlaySound(sounds.Start)
-					for i := 0; i < 3; i++ {
-						titleAnims[i].dest = tstate.destForOpening[i]
+				if tstate.timeline.done() && isTitleUpdated && isAllAnimUpdated {
+					if !tstate.titleMessageShown {
+						postMsg(gameMode, 0)
+						tstate.titleMessageShown = true
+					}
+				    if isActionReleased(ACTION_START) || isMouseButtonReleased(MOUSE_LEFT) {
+						if DEBUG { fmt.Println("Start released!") }
+	                    playSound("Start")
+						for i := 0; i < NUM_TITLE_ANIMS; i++ {
+							titleAnims[i].dest = tstate.destForOpening[i]
+						}
+						gameMode = OPENING
 					}
-					gameMode = OPENING
 				}
-			}
 
-			tstate.titleFrame++
+				// opening mode
+			    case OPENING:
 
-			// opening mode
-		    case OPENING:
-
-			frameDiv := openingFrame / 10
-			frameMod := openingFrame % 10
-			if frameDiv < BOARD_SIZE {
-				anim := &animals[frameDiv]
-				if frameMod == 0 {
-					if anim == titleAnims[0] {
-						jumpAnimal(anim, anim.dest, 24, 16)
-					} else {
-					    jumpAnimal(anim, anim.dest, 20, 4)
+				frameDiv := openingFrame / 10
+				frameMod := openingFrame % 10
+				if frameDiv < BOARD_SIZE {
+					anim := &animals[frameDiv]
+					if frameMod == 0 {
+						if anim == titleAnims[0] {
+							jumpAnimal(anim, anim.dest, 0.4, 115)
+						} else {
+						    jumpAnimal(anim, anim.dest, 0.33, 45)
+						}
 					}
+				    openingFrame++
+				} else if isAllAnimUpdated {
+					openingFrame = 0
+					gameMode = GAME_PLAY
 				}
-			    openingFrame++
-			} else if isAllAnimUpdated {
-				openingFrame = 0
-				gameMode = GAME_PLAY
-			}
 
-			// gameplay mode
-		    case GAME_PLAY:
+				// gameplay mode
+			    case GAME_PLAY:
 
-			if isAllAnimUpdated {
-				if msg.gameMode != gameMode { 
-					msg.gameMode = gameMode
-					msg.frames = 0
-				}
-
-				if !firstMoveMade && msg.frames == 0 {
-					setMsg(gameMode, 0)
-				}
+				cursor.update()
+				if isAllAnimUpdated {
+					if !chargeCanceled && isMouseButtonDown(MOUSE_RIGHT) && isRescueHeld() { cancelCharge(&chargeCanceled) }
+					if !chargeCanceled { drag.update(&board, &resquableIndex) }
+					if msgQueue.gameMode != gameMode {
+						msgQueue.reset(gameMode)
+						if !firstMoveMade { postMsg(gameMode, 0) }
+					}
 
-				if numAnimalLeft < BOARD_SIZE && resquedChanged { 
-					assert(resqued[BOARD_SIZE - numAnimalLeft - 1] != nil, "nil in resqued array")
+					if numAnimalLeft < BOARD_SIZE && resquedChanged { 
+						assert(resqued[BOARD_SIZE - numAnimalLeft - 1] != nil, "nil in resqued array")
 					
-					if !firstMoveMade {
-						firstMoveMade = true
-					    setMsg(gameMode, 1)
-					}
-					if !bigJumpMade && numAnimalLeft < BOARD_SIZE - 1 { 
-					    setMsg(gameMode, 1)
-					}
-					if bigJumpMade && !lastMsgShown{
-						lastMsgShown = true
-					    setMsg(gameMode, 2)
-					}
+						if !firstMoveMade {
+							firstMoveMade = true
+						    postMsg(gameMode, 1)
+						}
+						if !bigJumpMade && numAnimalLeft < BOARD_SIZE - 1 { 
+						    postMsg(gameMode, 1)
+						}
+						if bigJumpMade && !lastMsgShown{
+							lastMsgShown = true
+						    postMsg(gameMode, 2)
+						}
 
-					mostRecentResqueType := resqued[BOARD_SIZE - numAnimalLeft - 1].animType
-					for i := range resquableIndex { resquableIndex[i] = 0 }
-					numNextMoves := findResquables(&board, mostRecentResqueType, &resquableIndex)
-					resquedChanged = false
-					if numAnimalLeft == 0 {
-                        rl.PlaySound(sounds.Success)
-						gameMode = GAME_CLEAR
-					} else if numNextMoves == 0 {
-                        rl.PlaySound(sounds.Fail)
-						gameMode = GAME_OVER
-					}
+						mostRecentResqueType := resqued[BOARD_SIZE - numAnimalLeft - 1].animType
+						for i := range resquableIndex { resquableIndex[i] = 0 }
+						numNextMoves := findResquables(&board, mostRecentResqueType, &resquableIndex)
+						resquedChanged = false
+						if numAnimalLeft == 0 {
+	                        emit(Event{kind: EVENT_CLEARED})
+							gameMode = GAME_CLEAR
+						} else if numNextMoves == 0 {
+	                        emit(Event{kind: EVENT_DEAD_END})
+							gameMode = GAME_OVER
+						}
 
-					if DEBUG {
-					    fmt.Printf("numNextMoves: %d, %v\n", numNextMoves, resquableIndex)
-					    fmt.Printf("numAnimalLeft: %d\n", numAnimalLeft)
-					    printbd(&board)
+						if DEBUG {
+						    fmt.Printf("numNextMoves: %d, %v\n", numNextMoves, resquableIndex)
+						    fmt.Printf("numAnimalLeft: %d\n", numAnimalLeft)
+						    printbd(&board)
+						}
 					}
-				}
 
-				if rl.IsKeyDown(KEY_A) || (rl.IsMouseButtonDown(MOUSE_LEFT) && 
-				   isAnimRectClicked(board[FRONT_ROW_BASEINDEX])) {
-					if DEBUG { fmt.Println("A pressed!") }
-					if resquableIndex[0] != 0 { processKeyDown(board[FRONT_ROW_BASEINDEX]) }
-				} else if rl.IsKeyDown(KEY_S) || (rl.IsMouseButtonDown(MOUSE_LEFT) && 
-						  isAnimRectClicked(board[FRONT_ROW_BASEINDEX + 1])) {
-					if DEBUG { fmt.Println("S pressed!") }
-					if resquableIndex[1] != 0 { processKeyDown(board[FRONT_ROW_BASEINDEX+1]) }
-				} else if rl.IsKeyDown(KEY_D) || (rl.IsMouseButtonDown(MOUSE_LEFT) && 
-						  isAnimRectClicked(board[FRONT_ROW_BASEINDEX + 2])) {
-					if DEBUG { fmt.Println("D pressed!") }
-					if resquableIndex[2] != 0 { processKeyDown(board[FRONT_ROW_BASEINDEX+2]) }
-				} else if rl.IsKeyDown(KEY_F) || (rl.IsMouseButtonDown(MOUSE_LEFT) && 
-						  isAnimRectClicked(board[FRONT_ROW_BASEINDEX + 3])) {
-					if DEBUG { fmt.Println("F pressed!") }
-					if resquableIndex[3] != 0 { processKeyDown(board[FRONT_ROW_BASEINDEX+3]) }
-				} else if rl.IsKeyReleased(KEY_A) || (rl.IsMouseButtonReleased(MOUSE_LEFT) && 
-						  isAnimRectClicked(board[FRONT_ROW_BASEINDEX])) {
-					if DEBUG { fmt.Println("A released!") }
-					if resquableIndex[0] != 0 {
-						resqueAt(&board, &resqued, FRONT_ROW_BASEINDEX, numAnimalLeft)
-						numAnimalLeft--
-						resquedChanged = true
-                        msg = Message{}
-					}
-				} else if rl.IsKeyReleased(KEY_S) || (rl.IsMouseButtonReleased(MOUSE_LEFT) && 
-						  isAnimRectClicked(board[FRONT_ROW_BASEINDEX + 1])) {
-					if DEBUG { fmt.Println("S released!") }
-					if resquableIndex[1] != 0 {
-						resqueAt(&board, &resqued, FRONT_ROW_BASEINDEX + 1, numAnimalLeft)
-						numAnimalLeft--
-						resquedChanged = true
-                        msg = Message{}
-					}
-				} else if rl.IsKeyReleased(KEY_D) || (rl.IsMouseButtonReleased(MOUSE_LEFT) && 
-						  isAnimRectClicked(board[FRONT_ROW_BASEINDEX + 2])) {
-					if DEBUG { fmt.Println("D released!") }
-					if resquableIndex[2] != 0 {
-						resqueAt(&board, &resqued, FRONT_ROW_BASEINDEX + 2, numAnimalLeft)
-						numAnimalLeft--
+					if chargeCanceled {
+						if !isRescueHeld() { chargeCanceled = false }
+					} else if isActionDown(ACTION_RESCUE1) || cursor.isDown(0) || drag.isHeld(board[FRONT_ROW_BASEINDEX]) {
+						if DEBUG { fmt.Println("Rescue1 pressed!") }
+						if resquableIndex[0] != 0 { processKeyDown(board[FRONT_ROW_BASEINDEX]) }
+					} else if isActionDown(ACTION_RESCUE2) || cursor.isDown(1) || drag.isHeld(board[FRONT_ROW_BASEINDEX + 1]) {
+						if DEBUG { fmt.Println("Rescue2 pressed!") }
+						if resquableIndex[1] != 0 { processKeyDown(board[FRONT_ROW_BASEINDEX+1]) }
+					} else if isActionDown(ACTION_RESCUE3) || cursor.isDown(2) || drag.isHeld(board[FRONT_ROW_BASEINDEX + 2]) {
+						if DEBUG { fmt.Println("Rescue3 pressed!") }
+						if resquableIndex[2] != 0 { processKeyDown(board[FRONT_ROW_BASEINDEX+2]) }
+					} else if isActionDown(ACTION_RESCUE4) || cursor.isDown(3) || drag.isHeld(board[FRONT_ROW_BASEINDEX + 3]) {
+						if DEBUG { fmt.Println("Rescue4 pressed!") }
+						if resquableIndex[3] != 0 { processKeyDown(board[FRONT_ROW_BASEINDEX+3]) }
+					} else if isActionReleased(ACTION_RESCUE1) || cursor.isReleased(0) ||
+							  drag.isClicked(board[FRONT_ROW_BASEINDEX]) || drag.isDropped(board[FRONT_ROW_BASEINDEX]) {
+						if DEBUG { fmt.Println("Rescue1 released!") }
+						if resquableIndex[0] != 0 {
+							pushUndo(&animals, &board, &resqued, numAnimalLeft, bigJumpLeft, bigJumpMade, lastMsgShown)
+							resqueAt(&board, &resqued, FRONT_ROW_BASEINDEX, numAnimalLeft, landOnSpot)
+							numAnimalLeft--
+							resquedChanged = true
+	                        msgQueue.dismiss(MSG_TUTORIAL)
+						}
+					} else if isActionReleased(ACTION_RESCUE2) || cursor.isReleased(1) ||
+							  drag.isClicked(board[FRONT_ROW_BASEINDEX + 1]) || drag.isDropped(board[FRONT_ROW_BASEINDEX + 1]) {
+						if DEBUG { fmt.Println("Rescue2 released!") }
+						if resquableIndex[1] != 0 {
+							pushUndo(&animals, &board, &resqued, numAnimalLeft, bigJumpLeft, bigJumpMade, lastMsgShown)
+							resqueAt(&board, &resqued, FRONT_ROW_BASEINDEX + 1, numAnimalLeft, landOnSpot)
+							numAnimalLeft--
+							resquedChanged = true
+	                        msgQueue.dismiss(MSG_TUTORIAL)
+						}
+					} else if isActionReleased(ACTION_RESCUE3) || cursor.isReleased(2) ||
+							  drag.isClicked(board[FRONT_ROW_BASEINDEX + 2]) || drag.isDropped(board[FRONT_ROW_BASEINDEX + 2]) {
+						if DEBUG { fmt.Println("Rescue3 released!") }
+						if resquableIndex[2] != 0 {
+							pushUndo(&animals, &board, &resqued, numAnimalLeft, bigJumpLeft, bigJumpMade, lastMsgShown)
+							resqueAt(&board, &resqued, FRONT_ROW_BASEINDEX + 2, numAnimalLeft, landOnSpot)
+							numAnimalLeft--
+							resquedChanged = true
+	                        msgQueue.dismiss(MSG_TUTORIAL)
+						}
+					} else if isActionReleased(ACTION_RESCUE4) || cursor.isReleased(3) ||
+							  drag.isClicked(board[FRONT_ROW_BASEINDEX + 3]) || drag.isDropped(board[FRONT_ROW_BASEINDEX + 3]) {
+						if DEBUG { fmt.Println("Rescue4 released!") }
+						if resquableIndex[3] != 0 {
+							pushUndo(&animals, &board, &resqued, numAnimalLeft, bigJumpLeft, bigJumpMade, lastMsgShown)
+							resqueAt(&board, &resqued, FRONT_ROW_BASEINDEX + 3, numAnimalLeft, landOnSpot)
+							numAnimalLeft--
+							resquedChanged = true
+	                        msgQueue.dismiss(MSG_TUTORIAL)
+						}
+					} else if resqued[BOARD_SIZE - 1] != nil && (isActionReleased(ACTION_REPLAY) || 
+						(isMouseButtonReleased(MOUSE_LEFT) && 
+						 isAnimRectClicked(resqued[BOARD_SIZE - 1]))) {
+						if DEBUG { fmt.Println("Replay released!! Play Again!") }
+						resetState(&animals, &board, &resqued, &frontRowPos)
+						undoStack = nil
+						chargeCanceled = false
+						numAnimalLeft = BOARD_SIZE
+	                    bigJumpLeft = TOTAL_BIG_JUMP
+						resquableIndex = [NUM_COL]int{}
 						resquedChanged = true
-                        msg = Message{}
-					}
-				} else if rl.IsKeyReleased(KEY_F) || (rl.IsMouseButtonReleased(MOUSE_LEFT) && 
-						  isAnimRectClicked(board[FRONT_ROW_BASEINDEX + 3])) {
-					if DEBUG { fmt.Println("F released!") }
-					if resquableIndex[3] != 0 {
-						resqueAt(&board, &resqued, FRONT_ROW_BASEINDEX + 3, numAnimalLeft)
-						numAnimalLeft--
+						mostRecentResqueType = u8(0xFF)
+						numPossibleMoves = findResquables(&board, mostRecentResqueType, &resquableIndex)
+					} else if isActionReleased(ACTION_UNDO) && popUndo(&animals, &board, &resqued,
+							  &numAnimalLeft, &bigJumpLeft, &bigJumpMade, &lastMsgShown) {
+						if DEBUG { fmt.Println("Undo released!") }
+						chargeCanceled = false
+						resquableIndex = [NUM_COL]int{}
 						resquedChanged = true
-                        msg = Message{}
+						if numAnimalLeft == BOARD_SIZE {
+							numPossibleMoves = findResquables(&board, u8(0xFF), &resquableIndex)
+						}
+					} else if isActionReleased(ACTION_HINT) {
+						showHint(&board, &resqued, numAnimalLeft, bigJumpLeft)
 					}
-				} else if resqued[BOARD_SIZE - 1] != nil && (rl.IsKeyReleased(KEY_G) || 
-					(rl.IsMouseButtonReleased(MOUSE_LEFT) && 
-					 isAnimRectClicked(resqued[BOARD_SIZE - 1]))) {
-					if DEBUG { fmt.Println("G released!! Play Again!") }
-					resetState(&animals, &board, &resqued, &frontRowPos)
-					numAnimalLeft = BOARD_SIZE
-                    bigJumpLeft = TOTAL_BIG_JUMP
-					resquableIndex = [NUM_COL]int{}
-					resquedChanged = true
-					mostRecentResqueType = u8(0xFF)
-					numPossibleMoves = findResquables(&board, mostRecentResqueType, &resquableIndex)
 				}
-			}
 
-			// gameclear mode
-		    case GAME_CLEAR:
+				// gameclear mode
+			    case GAME_CLEAR:
 
-			if msg.gameMode != gameMode { setMsg(gameMode, 0) }
+				if msgQueue.gameMode != gameMode {
+					msgQueue.reset(gameMode)
+					postMsg(gameMode, 0)
+				}
 			
-			if isAllAnimUpdated {
-				if !willReplay {
-					if gameClearFrame < BOARD_SIZE {
-						anim := resqued[gameClearFrame]
-						jumpAnimal(anim, anim.pos, 18, 10)
+				if isAllAnimUpdated {
+					if !willReplay {
+						if gameClearFrame < BOARD_SIZE {
+							anim := resqued[gameClearFrame]
+							jumpAnimal(anim, anim.pos, 0.35, 250)
+						}
+						gameClearFrame++
+						if gameClearFrame >= BOARD_SIZE { gameClearFrame = 0 }
+					} else {
+						resetState(&animals, &board, &resqued, &frontRowPos)
+						undoStack = nil
+						chargeCanceled = false
+					    pauseFrames = FPS/2
+						gameMode = OPENING
+						msgQueue.clear()
+						willReplay = false
+						numAnimalLeft = BOARD_SIZE
+	                    bigJumpLeft = TOTAL_BIG_JUMP
+						resquableIndex = [NUM_COL]int{}
+						resquedChanged = true
+						mostRecentResqueType = u8(0xFF)  
+						numPossibleMoves = findResquables(&board, mostRecentResqueType, &resquableIndex)
 					}
-					gameClearFrame++
-					if gameClearFrame >= BOARD_SIZE { gameClearFrame = 0 }
-				} else {
-					resetState(&animals, &board, &resqued, &frontRowPos)
-				    time.Sleep(time.Millisecond * 500)
-					gameMode = OPENING
-					msg = Message{}
-					willReplay = false
-					numAnimalLeft = BOARD_SIZE
-                    bigJumpLeft = TOTAL_BIG_JUMP
-					resquableIndex = [NUM_COL]int{}
-					resquedChanged = true
-					mostRecentResqueType = u8(0xFF)  
-					numPossibleMoves = findResquables(&board, mostRecentResqueType, &resquableIndex)
 				}
-			}
 				
-			if !willReplay && rl.IsKeyReleased(KEY_G) || (rl.IsMouseButtonReleased(MOUSE_LEFT) && 
-			    isAnimRectClicked(resqued[BOARD_SIZE - 1 - numAnimalLeft])) {
-				if DEBUG { fmt.Println("G released on GAME_Clear! Play Again!") }
-                rl.PlaySound(sounds.Start)
-				for _, anim := range resqued {
-					jumpAnimal(anim, Vec2{anim.pos.X, -ANIM_SIZE} , 24, 20)
+				if !willReplay && isActionReleased(ACTION_REPLAY) || (isMouseButtonReleased(MOUSE_LEFT) && 
+				    isAnimRectClicked(resqued[BOARD_SIZE - 1 - numAnimalLeft])) {
+					if DEBUG { fmt.Println("Replay released on GAME_Clear! Play Again!") }
+	                playSound("Start")
+					for _, anim := range resqued {
+						jumpAnimal(anim, Vec2{anim.pos.X, -ANIM_SIZE}, 0.25, 0)
+					}
+					willReplay = true
 				}
-				willReplay = true
-			}
 
-			// gamover mode
-		    case GAME_OVER:
+				// gamover mode
+			    case GAME_OVER:
 			
-			if msg.gameMode != gameMode { setMsg(gameMode, 0) }
+				if msgQueue.gameMode != gameMode {
+					msgQueue.reset(gameMode)
+					postMsg(gameMode, 0)
+				}
 
-			if isAllAnimUpdated {
-				if !willReplay {
-					for i := 0; i < BOARD_SIZE; i++ {
-						if board[i] != nil && board[i].height >= MIN_ANIM_HEIGHT*5 { 
-							board[i].height -= 1 
+				if isAllAnimUpdated {
+					if !willReplay {
+						for i := 0; i < BOARD_SIZE; i++ {
+							if board[i] != nil && board[i].height >= MIN_ANIM_HEIGHT*5 { 
+								board[i].height -= 1 
+							}
 						}
+					} else {
+						resetState(&animals, &board, &resqued, &frontRowPos)
+						undoStack = nil
+						chargeCanceled = false
+					    pauseFrames = FPS/2
+						gameMode = OPENING
+						willReplay = false
+						msgQueue.clear()
+						numAnimalLeft = BOARD_SIZE
+	                    bigJumpLeft = TOTAL_BIG_JUMP
+						resquableIndex = [NUM_COL]int{}
+						resquedChanged = true
+						mostRecentResqueType = u8(0xFF)  
+						numPossibleMoves = findResquables(&board, mostRecentResqueType, &resquableIndex)
 					}
-				} else {
-					resetState(&animals, &board, &resqued, &frontRowPos)
-				    time.Sleep(time.Millisecond * 500)
-					gameMode = OPENING
-					willReplay = false
-					msg = Message{}
-					numAnimalLeft = BOARD_SIZE
-                    bigJumpLeft = TOTAL_BIG_JUMP
+	            }
+
+				if isAllAnimUpdated && !willReplay && isActionReleased(ACTION_UNDO) &&
+				   popUndo(&animals, &board, &resqued, &numAnimalLeft, &bigJumpLeft, &bigJumpMade, &lastMsgShown) {
+					if DEBUG { fmt.Println("Undo released on GAME_OVER!") }
+					chargeCanceled = false
+					gameMode = GAME_PLAY
 					resquableIndex = [NUM_COL]int{}
 					resquedChanged = true
-					mostRecentResqueType = u8(0xFF)  
-					numPossibleMoves = findResquables(&board, mostRecentResqueType, &resquableIndex)
+					if numAnimalLeft == BOARD_SIZE {
+						numPossibleMoves = findResquables(&board, u8(0xFF), &resquableIndex)
+					}
 				}
-            }
 
-				if !willReplay && rl.IsKeyReleased(KEY_G) || (rl.IsMouseButtonReleased(MOUSE_LEFT) && 
-				    isAnimRectClicked(resqued[BOARD_SIZE - 1 - numAnimalLeft])) {
-					if DEBUG { fmt.Println("G released on GAME_OVER! Play Again!") }
-                    rl.PlaySound(sounds.Start)
-					for _, anim := range board { 
-						if anim != nil {jumpAnimal(anim, Vec2{anim.pos.X, -ANIM_SIZE}, 24, 20)}
-					}
-					for _, anim := range resqued { 
-						if anim != nil {jumpAnimal(anim, Vec2{anim.pos.X, -ANIM_SIZE}, 24, 20)}
+					if !willReplay && isActionReleased(ACTION_REPLAY) || (isMouseButtonReleased(MOUSE_LEFT) && 
+					    isAnimRectClicked(resqued[BOARD_SIZE - 1 - numAnimalLeft])) {
+						if DEBUG { fmt.Println("Replay released on GAME_OVER! Play Again!") }
+	                    playSound("Start")
+						for _, anim := range board { 
+							if anim != nil {jumpAnimal(anim, Vec2{anim.pos.X, -ANIM_SIZE}, 0.25, 0)}
+						}
+						for _, anim := range resqued { 
+							if anim != nil {jumpAnimal(anim, Vec2{anim.pos.X, -ANIM_SIZE}, 0.25, 0)}
+						}
+						willReplay = true
 					}
-					willReplay = true
-				}
-		}
+			}
 
-		isAllAnimUpdated = updateAnimState(&animals, &board, &resqued, &frontRowPos, 
-		                                   &numAnimalLeft, &resquedChanged, &bigJumpMade, 
-                                           &bigJumpLeft, lastMsgShown)
+			updateTweens(SIM_DT)
+			isAllAnimUpdated = updateAnimState(&animals)
+
+			consumeInputEdges()
+		}
+		updateMusic(gameMode)
+		interp := accumulator / SIM_DT
 
         // Render
         rl.BeginDrawing()
@@ -1087,58 +1228,47 @@ func main() {
 			
 			if gameMode == TITLE {
 				
-				drawTitle(&title)
-				for _, anim := range titleAnims { drawAnimal(anim) }
+				drawTitle(&title, interp)
+				for _, anim := range titleAnims { drawAnimal(anim, interp) }
 
 			} else {
 
+				if gameMode == GAME_PLAY { cursor.draw(&frontRowPos, &resquableIndex) }
 				for i := 0; i < BOARD_SIZE; i++ {
-					if board[i] != nil { drawAnimal(board[i]) }
+					if board[i] != nil { drawAnimal(board[i], interp) }
 				}
 				
 				for i := 0; i < BOARD_SIZE - numAnimalLeft; i++ {
-					if resqued[i] != nil { drawAnimal(resqued[i]) }
+					if resqued[i] != nil { drawAnimal(resqued[i], interp) }
 				}
+				if gameMode == GAME_PLAY { drag.draw(interp) }
 			}
 
 			// draw message
-			if gameMode == msg.gameMode {
+			if gameMode == msgQueue.gameMode && msgQueue.current != nil {
 				fontColor := rl.Gold
-				if msg.duration == INDEFINITE {
-					if msg.frames < FPS*3 {
-						alpha := (msg.frames*2 % 255*2) 
-						if alpha > 255 { alpha = 255*2 - alpha }
-						msg.alpha = u8(alpha)
-					} else if msg.alpha <= 253 {
-						msg.alpha += 2
-					}
-				} else {
-					if msg.frames <= msg.duration {
-						alpha := (msg.frames*2 % 255*2) 
-						if alpha > 255 { alpha = 255*2 - alpha }
-						msg.alpha = u8(alpha)
-					} else {
-						if msg.alpha <= 1 {
-							msg.alpha = 0
-						} else {
-							msg.alpha -= 2
-						}
-					}
-				}
+				fontColor.A = u8(msgQueue.current.alpha)
+				msgQueue.current.layout.draw(fontColor)
+			}
 
-				fontColor.A = u8(msg.alpha)
-				if msg.l2 == "" {
-					rl.DrawText(msg.l1, 0, MSG_POS_Y, DEFAULT_FONT_SIZE, fontColor)
-				} else {
-					rl.DrawText(msg.l1, 0, MSG_POS_Y - DEFAULT_FONT_SIZE/2,
-								DEFAULT_FONT_SIZE, fontColor)
-					rl.DrawText(msg.l2, 0, MSG_POS_Y + DEFAULT_FONT_SIZE/2, 
-								DEFAULT_FONT_SIZE, fontColor)
-				}
+			if rebindScreen.open {
+				rebindScreen.draw()
+			} else if paused {
+				drawPaused()
 			}
         }
         rl.EndDrawing()
     }
 
 	unloadSounds()
+	unloadMusic()
+	unloadTextures(textures)
+	unloadMsgFont()
+}
+
+func drawPaused() {
+	drawOverlay()
+	layout := layoutText(expandBindings(tr("pause.title")), Vec2{WINDOW_WIDTH/2, WINDOW_HEIGHT/2},
+						 MSG_AREA_WIDTH, MSG_AREA_HEIGHT, DEFAULT_FONT_SIZE*1.5)
+	layout.draw(rl.RayWhite)
 }
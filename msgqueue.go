@@ -0,0 +1,139 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Messages are shown one at a time from a queue. A posted message waits while a more
+// important one is shown, and replaces a less or equally important one once that one
+// has been shown for its minFrames. An INDEFINITE message replaced by a more important
+// one goes back to the queue and is shown again when it's done.
+type MsgPriority int
+const (
+	MSG_TUTORIAL MsgPriority = iota
+	MSG_INFO
+	MSG_WARNING
+	MSG_RESULT
+)
+
+type MsgQueue struct {
+	current *Message
+	pending []*Message  // by priority, then by the order they were posted
+	fadingOut bool
+	gameMode GameMode  // the mode the messages are shown in
+}
+
+const (
+	MIN_MSG_FRAMES = FPS*2
+	MSG_FADE_IN_DURATION = 0.3
+	MSG_FADE_OUT_DURATION = 0.3
+	MSG_EXPIRE_DURATION = 2  // the fade out of the messages whose duration has passed
+)
+
+var msgQueue MsgQueue
+
+// Posts the warnings of the big jumps left. lastMsgShown is whether the warning
+// after the first big jump has been shown.
+func subscribeMessages(lastMsgShown *bool) {
+	subscribe(EVENT_SCATTERED, func(e Event) {
+		if *lastMsgShown && e.bigJumpsLeft == 1 { postMsg(GAME_PLAY, 3) }
+		if e.bigJumpsLeft == 0 { postMsg(GAME_PLAY, 4) }
+	})
+}
+
+// Posts the message msgNum of the scripts of gameMode
+func postMsg(gameMode GameMode, msgNum int) {
+	assert(gameMode > 0, "GameMode is less than 1 in the postMsg function")
+	if msgNum >= len(scripts.msgs[gameMode-1]) {
+		if DEBUG {
+		    fmt.Printf("msgNum %d is greater than the msg len for game gameMode %d!\n", msgNum, gameMode)
+	    }
+		return
+	}
+
+	m := scripts.msgs[gameMode-1][msgNum]
+	q := &msgQueue
+	if q.current != nil && q.current.id == m.id && !q.fadingOut { return }
+	for _, p := range q.pending {
+		if p.id == m.id { return }
+	}
+	q.push(&m)
+}
+
+func (q *MsgQueue) push(m *Message) {
+	i := sort.Search(len(q.pending), func(i int) bool { return q.pending[i].priority < m.priority })
+	q.pending = append(q.pending, nil)
+	copy(q.pending[i+1:], q.pending[i:])
+	q.pending[i] = m
+}
+
+// Clears the messages and shows the ones posted from now on in gameMode
+func (q *MsgQueue) reset(gameMode GameMode) {
+	q.clear()
+	q.gameMode = gameMode
+}
+
+func (q *MsgQueue) clear() {
+	if q.current != nil { cancelTweens(&q.current.alpha) }
+	q.current, q.pending, q.fadingOut = nil, nil, false
+}
+
+// Fades out the current message and drops the pending ones up to the priority
+func (q *MsgQueue) dismiss(priority MsgPriority) {
+	kept := q.pending[:0]
+	for _, m := range q.pending {
+		if m.priority > priority { kept = append(kept, m) }
+	}
+	q.pending = kept
+	if q.current != nil && q.current.priority <= priority { q.fadeOut() }
+}
+
+// Advances the current message by a step and switches to the next one when it's due
+func (q *MsgQueue) update() {
+	if q.current != nil { q.current.frames++ }
+	if len(q.pending) == 0 || q.fadingOut { return }
+
+	next := q.pending[0]
+	switch {
+	case q.current == nil:
+		q.pending = q.pending[1:]
+		q.show(next)
+	case next.priority >= q.current.priority && q.current.frames >= q.current.minFrames:
+		if next.priority > q.current.priority && q.current.duration == INDEFINITE {
+			q.push(q.current)
+		}
+		q.fadeOut()
+	}
+}
+
+// Fades the message in and blinks it for a while, then keeps it shown if its duration
+// is INDEFINITE or fades it out otherwise.
+func (q *MsgQueue) show(m *Message) {
+	q.current, q.fadingOut = m, false
+	m.frames, m.alpha = 0, 0
+	m.layout = layoutMsg(expandBindings(m.lines))
+
+	blinkFrames := FPS*3
+	if m.duration != INDEFINITE { blinkFrames = m.duration }
+	halfBlink := f32(MSG_BLINK_FRAMES)/2/FPS
+
+	tw := tweenTo(&m.alpha, 255, MSG_FADE_IN_DURATION, easeOutQuad)
+	for i := 0; i < blinkFrames; i += MSG_BLINK_FRAMES {
+		tw = tw.then(&m.alpha, 0, halfBlink, easeInOutQuad).
+				then(&m.alpha, 255, halfBlink, easeInOutQuad)
+	}
+	if m.duration != INDEFINITE {
+		tw.then(&m.alpha, 0, MSG_EXPIRE_DURATION, easeLinear).onDone = q.finish
+	}
+}
+
+func (q *MsgQueue) fadeOut() {
+	if q.current == nil || q.fadingOut { return }
+	q.fadingOut = true
+	tweenTo(&q.current.alpha, 0, MSG_FADE_OUT_DURATION, easeLinear).onDone = q.finish
+}
+
+func (q *MsgQueue) finish() {
+	q.current, q.fadingOut = nil, false
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"github.com/gen2brain/raylib-go/raylib"
+	"errors"
+	"path"
+)
+
+// Background music, a looping track per group of game modes streamed from the assets'
+// MUSIC_DIR as {track}.ogg(or .mp3, .wav...). Tracks crossfade when the mode changes
+// and duck under the sounds of duckingSounds. No track file is shipped: a missing track,
+// or any with the retro audio theme, is synthesized from the tune of musicTunes.
+type MusicTrack struct {
+	music rl.Music
+	data []byte  // the encoded file, streamed from memory while the track is loaded
+	gain f32     // of the crossfade, in [0, 1]
+	playing bool
+}
+
+type MusicPlayer struct {
+	tracks map[string]*MusicTrack
+	current string
+	duck f32  // gain under the ducking sounds, 1 when not ducked
+}
+
+const (
+	MUSIC_DIR = "music"
+	CROSSFADE_DURATION = 1.5
+	DUCK_GAIN = 0.35
+	DUCK_ATTACK = 0.08
+	DUCK_RELEASE = 0.6
+)
+
+var musicExts = []string{".ogg", ".mp3", ".wav", ".flac", ".xm", ".mod"}
+var musicTunes = map[string]Tune{"title": titleTune, "play": playTune, "end": endTune}
+var duckingSounds = map[string]bool{"BigLand": true, "Success": true, "Fail": true}
+
+var music MusicPlayer
+
+func trackForMode(mode GameMode) string {
+	switch mode {
+	case TITLE:
+		return "title"
+	case OPENING, GAME_PLAY:
+		return "play"
+	}
+	return "end"
+}
+
+func loadMusic(report *AssetReport) {
+	music = MusicPlayer{tracks: map[string]*MusicTrack{}, duck: 1}
+	for _, name := range []string{"title", "play", "end"} {
+		var track *MusicTrack
+		if settings.AudioTheme != RETRO_AUDIO_THEME { track = loadMusicTrack(report, name) }
+		if track == nil { track = newMusicTrack(".wav", synthWav(musicTunes[name].synth)) }
+		if track != nil { music.tracks[name] = track }
+	}
+}
+
+// Loads the first file of the track found in MUSIC_DIR, nil if there's none or it can't be decoded
+func loadMusicTrack(report *AssetReport, name string) *MusicTrack {
+	for _, ext := range musicExts {
+		file := path.Join(MUSIC_DIR, name + ext)
+		data, err := readAsset(file)
+		if err != nil || len(data) == 0 { continue }
+
+		track := newMusicTrack(ext, data)
+		if track == nil { report.fail(file, errors.New("the music can't be decoded"), "synthesized") }
+		return track
+	}
+	return nil
+}
+
+func newMusicTrack(ext string, data []byte) *MusicTrack {
+	m := rl.LoadMusicStreamFromMemory(ext, data, i32(len(data)))
+	if m.FrameCount == 0 { return nil }
+	m.Looping = true
+	return &MusicTrack{music: m, data: data}
+}
+
+func unloadMusic() {
+	for _, track := range music.tracks {
+		cancelTweens(&track.gain)
+		rl.UnloadMusicStream(track.music)
+	}
+	music.tracks = nil
+}
+
+// Crossfades to the track of the mode when it changes, and feeds the playing streams.
+// Called on every rendered frame.
+func updateMusic(mode GameMode) {
+	if name := trackForMode(mode); name != music.current { crossfadeTo(name) }
+
+	for _, track := range music.tracks {
+		if !track.playing { continue }
+		rl.SetMusicVolume(track.music, busVolume(BUS_MUSIC) * track.gain * music.duck)
+		rl.UpdateMusicStream(track.music)
+	}
+}
+
+func crossfadeTo(name string) {
+	if old, ok := music.tracks[music.current]; ok {
+		tweenTo(&old.gain, 0, CROSSFADE_DURATION, easeInOutQuad).onDone = func() {
+			rl.StopMusicStream(old.music)
+			old.playing = false
+		}
+	}
+	music.current = name
+
+	track, ok := music.tracks[name]
+	if !ok { return }
+	if !track.playing {
+		track.gain = 0
+		rl.PlayMusicStream(track.music)
+		track.playing = true
+	}
+	tweenTo(&track.gain, 1, CROSSFADE_DURATION, easeInOutQuad)
+}
+
+// Lowers the music for the length of the sound
+func duckMusic(sound rl.Sound) {
+	length := f32(0)
+	if sound.Stream.SampleRate > 0 { length = f32(sound.FrameCount) / f32(sound.Stream.SampleRate) }
+	tweenTo(&music.duck, DUCK_GAIN, DUCK_ATTACK, easeOutQuad).
+		then(&music.duck, DUCK_GAIN, length, easeLinear).
+		then(&music.duck, 1, DUCK_RELEASE, easeInQuad)
+}
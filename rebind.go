@@ -0,0 +1,107 @@
+package main
+
+import (
+	"github.com/gen2brain/raylib-go/raylib"
+	"fmt"
+)
+
+// The rebinding screen, opened and closed with F1(or Back) over a paused game. Up and Down
+// select an action, Enter(or A) waits for the next key or gamepad button and binds it
+// to the action in place of its keys or buttons, Backspace(or X) resets all the bindings
+// to the defaults. Changes are saved right away.
+type RebindScreen struct {
+	open bool
+	selected Action
+	waiting bool  // for the key of the selected action
+}
+
+const (
+	REBIND_ROW_HEIGHT = DEFAULT_FONT_SIZE*1.6
+	REBIND_TOP_Y = WINDOW_HEIGHT*0.2
+	OVERLAY_ALPHA = 0.75
+)
+
+var rebindScreen RebindScreen
+
+func (r *RebindScreen) toggle() {
+	r.open, r.waiting = !r.open, false
+}
+
+// Called on each simulation step while the screen is open
+func (r *RebindScreen) update() {
+	if r.waiting {
+		code := input.keyPressed
+		if code == 0 && input.padPressed != 0 { code = padCode(input.padPressed) }
+		if code == 0 { return }
+		r.waiting = false
+		// the menu keys and ESC(which closes the window) can't be bound
+		if code == KEY_ESCAPE || isFixedKey(code) { return }
+		bind(r.selected, code)
+		saveBindingsOrReport()
+		return
+	}
+
+	switch {
+	case isMenuUp():
+		r.selected = (r.selected + NUM_ACTIONS - 1) % NUM_ACTIONS
+	case isMenuDown():
+		r.selected = (r.selected + 1) % NUM_ACTIONS
+	case isMenuSelected():
+		r.waiting = true
+	case isMenuReset():
+		bindings = defaultBindings
+		watchKeys()
+		saveBindingsOrReport()
+	}
+}
+
+func (r *RebindScreen) draw() {
+	drawOverlay()
+	title := layoutText(tr("bindings.title"), Vec2{WINDOW_WIDTH/2, REBIND_TOP_Y/2},
+						MSG_AREA_WIDTH, REBIND_TOP_Y, DEFAULT_FONT_SIZE*1.5)
+	title.draw(rl.RayWhite)
+
+	for action := Action(0); action < NUM_ACTIONS; action++ {
+		color := rl.RayWhite
+		if action == r.selected { color = rl.Gold }
+		y := REBIND_TOP_Y + REBIND_ROW_HEIGHT*f32(action)
+
+		drawMsgText(trLine("action." + actionNames[action]), Vec2{MARGIN_WIDTH*3, y}, DEFAULT_FONT_SIZE, color)
+		keys := actionKeyNames(action)
+		if action == r.selected && r.waiting { keys = trLine("bindings.waiting") }
+		width := measureMsgText(keys, DEFAULT_FONT_SIZE).X
+		drawMsgText(keys, Vec2{WINDOW_WIDTH - MARGIN_WIDTH*3 - width, y}, DEFAULT_FONT_SIZE, color)
+	}
+
+	helpY := REBIND_TOP_Y + REBIND_ROW_HEIGHT*f32(NUM_ACTIONS + 1)
+	help := layoutText(tr("bindings.help"), Vec2{WINDOW_WIDTH/2, helpY}, MSG_AREA_WIDTH,
+					   DEFAULT_FONT_SIZE*3, DEFAULT_FONT_SIZE*0.8)
+	help.draw(rl.LightGray)
+}
+
+// The first line of the message id, the id itself if it has no lines
+func trLine(id string) string {
+	if lines := tr(id); len(lines) > 0 { return lines[0] }
+	return id
+}
+
+// Darkens the game under a screen drawn over it
+func drawOverlay() {
+	rl.DrawRectangle(0, 0, WINDOW_WIDTH, WINDOW_HEIGHT, rl.Fade(rl.Black, OVERLAY_ALPHA))
+}
+
+func isFixedKey(code i32) bool {
+	for _, k := range fixedKeys {
+		if k == code { return true }
+	}
+	for _, button := range fixedPadButtons {
+		if padCode(button) == code { return true }
+	}
+	return false
+}
+
+// Not while a replay or a script plays, its bindings aren't the player's
+func saveBindingsOrReport() {
+	if replaying() { return }
+	if err := saveBindings(); err != nil { fmt.Println("Failed to save the bindings:", err) }
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// User settings, kept as JSON in the user config directory
+// (e.g. ~/.config/alogic/settings.json on Linux). A missing file means the defaults.
+type Settings struct {
+	Language string `json:"language,omitempty"`  // e.g. "en", empty to follow the locale
+	Theme string `json:"theme,omitempty"`  // the directory name in assets/themes, empty for the default
+	Volumes Volumes `json:"volumes"`
+	Muted bool `json:"muted,omitempty"`
+	AudioTheme string `json:"audioTheme,omitempty"`  // "retro" for synthesized sounds and music
+}
+
+// The volumes of the audio buses, in [0, 1]
+type Volumes struct {
+	Master f32 `json:"master"`
+	SFX f32 `json:"sfx"`
+	Music f32 `json:"music"`
+}
+
+var defaultSettings = Settings{Volumes: Volumes{Master: 1, SFX: 1, Music: 1}}
+var settings = defaultSettings
+
+func settingsPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil { return "", err }
+	return filepath.Join(dir, "alogic", "settings.json"), nil
+}
+
+func loadSettings() {
+	path, err := settingsPath()
+	if err != nil { return }
+	data, err := os.ReadFile(path)
+	if err != nil { return }
+	// the keys missing from the file keep their defaults
+	if err := json.Unmarshal(data, &settings); err != nil {
+		fmt.Printf("Ignoring the settings in %s: %v\n", path, err)
+		settings = defaultSettings
+	}
+}
+
+func saveSettings() error {
+	path, err := settingsPath()
+	if err != nil { return err }
+	data, err := json.MarshalIndent(&settings, "", "\t")
+	if err != nil { return err }
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil { return err }
+	return os.WriteFile(path, data, 0644)
+}
+
+// Not while a replay or a script plays, its theme and volume changes aren't the player's
+func saveSettingsOrReport() {
+	if replaying() { return }
+	if err := saveSettings(); err != nil { fmt.Println("Failed to save the settings:", err) }
+}
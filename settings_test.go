@@ -0,0 +1,24 @@
+package main
+
+import (
+	"os"
+	"testing"
+)
+
+func TestSettingsNotSavedWhileReplaying(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	path, err := settingsPath()
+	if err != nil { t.Fatal(err) }
+	saved := settings
+	defer func() { settings = saved }()
+
+	holdPlayerBindings()
+	defer restorePlayerBindings()
+	toggleMute()
+	changeVolume(-0.5)
+	if _, err := os.Stat(path); !os.IsNotExist(err) { t.Fatalf("the settings were saved during a replay (%v)", err) }
+
+	restorePlayerBindings()
+	toggleMute()
+	if _, err := os.Stat(path); err != nil { t.Errorf("the settings weren't saved after the replay: %v", err) }
+}
@@ -0,0 +1,297 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"os/signal"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// The solver works on animTypes only(0 for an empty spot) so it can run without a window.
+// Rescues and big jump scatters follow resqueAt and scatterResqued move for move.
+
+type SolveMove struct {
+	col int   // front row column the animal is rescued from
+	big bool  // rescued with a BIG JUMP that scatters the previously rescued
+}
+
+type SolveResult struct {
+	seed i64
+	solvable bool
+	moves []SolveMove
+	nodes int  // number of states visited, a rough measure of the difficulty
+}
+
+type solverState struct {
+	board [BOARD_SIZE]u8
+	resqued [BOARD_SIZE]u8
+	numResqued int
+	bigJumpLeft int
+}
+
+type solver struct {
+	ctx context.Context
+	deadEnds map[solverState]bool
+	moves []SolveMove
+	nodes int
+	maxNodes int  // 0 for no limit
+}
+
+var errTooManyNodes = errors.New("the search visited too many states")
+
+// Returns the animTypes of the board shuffled the same way shuffleBoard does,
+// but with a seeded source so the same seed always gives the same board.
+func shuffledTypes(seed i64) [BOARD_SIZE]u8 {
+	types := [BOARD_SIZE]u8{}
+	for i := 0; i < BOARD_SIZE; i++ {
+		row, col := i / NUM_COL, i % NUM_COL
+		types[i] = u8(1) << row << NUM_KIND | u8(1) << col
+	}
+
+	r := rand.New(rand.NewSource(seed))
+	for i := 0; i < BOARD_SIZE - 2; i++ {
+		indexToSwap := i + 1 + r.Intn(BOARD_SIZE - 1 - i)
+		types[i], types[indexToSwap] = types[indexToSwap], types[i]
+	}
+	types[0], types[BOARD_SIZE - 1] = types[BOARD_SIZE - 1], types[0]
+
+	return types
+}
+
+func boardTypes(board *[BOARD_SIZE]*Animal) [BOARD_SIZE]u8 {
+	types := [BOARD_SIZE]u8{}
+	for i, anim := range board {
+		if anim != nil { types[i] = anim.animType }
+	}
+	return types
+}
+
+func solveBoard(ctx context.Context, types [BOARD_SIZE]u8) (SolveResult, error) {
+	return solveState(ctx, solverState{board: types, bigJumpLeft: TOTAL_BIG_JUMP}, 0)
+}
+
+// Solves the game from the state, e.g. the one in play for a hint. Gives up with
+// errTooManyNodes after visiting maxNodes states, unless maxNodes is 0.
+func solveState(ctx context.Context, st solverState, maxNodes int) (SolveResult, error) {
+	s := solver{ctx: ctx, deadEnds: map[solverState]bool{}, maxNodes: maxNodes}
+	solvable, err := s.search(st)
+	if err != nil { return SolveResult{}, err }
+
+	return SolveResult{solvable: solvable, moves: s.moves, nodes: s.nodes}, nil
+}
+
+func (s *solver) search(st solverState) (bool, error) {
+	if st.numResqued == BOARD_SIZE { return true, nil }
+	if s.deadEnds[st] { return false, nil }
+
+	s.nodes++
+	if s.nodes % 1024 == 0 && s.ctx.Err() != nil { return false, s.ctx.Err() }
+	if s.maxNodes > 0 && s.nodes > s.maxNodes { return false, errTooManyNodes }
+
+	lastType := u8(0xFF)
+	if st.numResqued > 0 { lastType = st.resqued[st.numResqued - 1] }
+
+	for col := 0; col < NUM_COL; col++ {
+		animType := st.board[FRONT_ROW_BASEINDEX + col]
+		if animType == 0 || animType & lastType == 0 { continue }
+
+		for _, big := range [2]bool{false, true} {
+			// a big jump without anyone to scatter is just a regular rescue
+			if big && (st.bigJumpLeft == 0 || st.numResqued == 0) { continue }
+
+			next := st
+			resqueTypeAt(&next, col)
+			if big { scatterTypes(&next) }
+
+			s.moves = append(s.moves, SolveMove{col, big})
+			found, err := s.search(next)
+			if found || err != nil { return found, err }
+			s.moves = s.moves[:len(s.moves) - 1]
+		}
+	}
+
+	s.deadEnds[st] = true
+	return false, nil
+}
+
+func resqueTypeAt(st *solverState, col int) {
+	i := FRONT_ROW_BASEINDEX + col
+	st.resqued[st.numResqued] = st.board[i]
+	st.numResqued++
+
+	for i >= 0 && st.board[i] != 0 {
+		if i < NUM_COL || st.board[i - NUM_COL] == 0 {
+			st.board[i] = 0
+			break
+		}
+		st.board[i] = st.board[i - NUM_COL]
+		i -= NUM_COL
+	}
+}
+
+func scatterTypes(st *solverState) {
+	maxIndexToScatter := st.numResqued - 2
+	indexToMoveToBoard := maxIndexToScatter
+
+	for col := 0; col < NUM_COL && indexToMoveToBoard >= 0; col++ {
+		if st.board[col] != 0 { continue }
+
+		typeToPush := st.resqued[indexToMoveToBoard]
+		st.resqued[indexToMoveToBoard] = 0
+		for i := FRONT_ROW_BASEINDEX + col; i >= 0; i -= NUM_COL {
+			st.board[i], typeToPush = typeToPush, st.board[i]
+			if typeToPush == 0 { break }
+		}
+		indexToMoveToBoard--
+	}
+
+	st.resqued[indexToMoveToBoard + 1] = st.resqued[maxIndexToScatter + 1]
+	if indexToMoveToBoard != maxIndexToScatter { st.resqued[maxIndexToScatter + 1] = 0 }
+	st.numResqued = indexToMoveToBoard + 2
+	st.bigJumpLeft--
+}
+
+// Solves the boards of the given seeds with a pool of workers(NumCPU when workers < 1).
+// results[i] always belongs to seeds[i] regardless of the order the workers finish in.
+// progress, if not nil, is called from the calling goroutine after each solved board.
+// On cancellation, the boards solved so far are returned with the context error.
+func solveBatch(ctx context.Context, seeds []i64, workers int,
+				progress func(done, total int)) ([]SolveResult, error) {
+	if workers < 1 { workers = runtime.NumCPU() }
+
+	results := make([]SolveResult, len(seeds))
+	jobs := make(chan int)
+	solved := make(chan int)
+	errs := make(chan error, workers)
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				result, err := solveBoard(ctx, shuffledTypes(seeds[i]))
+				if err != nil {
+					errs <- err
+					return
+				}
+				result.seed = seeds[i]
+				results[i] = result
+				solved <- i
+			}
+		}()
+	}
+
+	go func() {
+		defer close(jobs)
+		for i := range seeds {
+			select {
+			case jobs <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	go func() {
+		wg.Wait()
+		close(solved)
+	}()
+
+	done := 0
+	for range solved {
+		done++
+		if progress != nil { progress(done, len(seeds)) }
+	}
+
+	if ctx.Err() != nil { return results, ctx.Err() }
+	select {
+	case err := <-errs:
+		return results, err
+	default:
+	}
+	return results, nil
+}
+
+// The most boards -solve takes at once
+const MAX_SOLVE_SEEDS = 1_000_000
+
+// Parses a seed range like "100-199" or a single seed like "42". Seeds may be negative,
+// e.g. "-5" or "-5--1".
+func parseSeedRange(s string) ([]i64, error) {
+	from, to, isRange := s, "", false
+	// the range dash is after the sign of the first seed
+	sign := 0
+	if strings.HasPrefix(s, "-") { sign = 1 }
+	if i := strings.Index(s[sign:], "-"); i >= 0 {
+		from, to, isRange = s[:sign + i], s[sign + i + 1:], true
+	}
+	first, err := strconv.ParseInt(from, 10, 64)
+	if err != nil { return nil, fmt.Errorf("invalid seed %q", from) }
+	last := first
+	if isRange {
+		last, err = strconv.ParseInt(to, 10, 64)
+		if err != nil { return nil, fmt.Errorf("invalid seed %q", to) }
+	}
+	if last < first { return nil, fmt.Errorf("invalid seed range %q", s) }
+	// in uint64 as last - first overflows i64 for the widest ranges
+	if uint64(last) - uint64(first) >= MAX_SOLVE_SEEDS {
+		return nil, fmt.Errorf("the seed range %q has more than %d seeds", s, MAX_SOLVE_SEEDS)
+	}
+
+	n := int(uint64(last) - uint64(first)) + 1
+	seeds := make([]i64, n)
+	for i := range seeds { seeds[i] = first + i64(i) }
+	return seeds, nil
+}
+
+// Solves the seed range given with the -solve flag and prints the results in seed order.
+// Returns the exit code.
+func runSolve(seedRange string, workers int) int {
+	seeds, err := parseSeedRange(seedRange)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	results, err := solveBatch(ctx, seeds, workers, func(done, total int) {
+		fmt.Fprintf(os.Stderr, "\rsolved %d/%d", done, total)
+	})
+	fmt.Fprintln(os.Stderr)
+
+	numSolved, numSolvable, totalNodes := 0, 0, 0
+	for _, r := range results {
+		if r.nodes == 0 { continue }  // not solved before the cancellation
+		numBig := 0
+		for _, m := range r.moves {
+			if m.big { numBig++ }
+		}
+		if r.solvable {
+			numSolvable++
+			fmt.Printf("seed %d: solvable, %d big jumps, %d nodes\n", r.seed, numBig, r.nodes)
+		} else {
+			fmt.Printf("seed %d: dead-end, %d nodes\n", r.seed, r.nodes)
+		}
+		numSolved++
+		totalNodes += r.nodes
+	}
+	if numSolved > 0 {
+		fmt.Printf("%d/%d solvable, %.1f nodes on average\n", numSolvable, numSolved,
+				   f64(totalNodes) / f64(numSolved))
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
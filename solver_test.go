@@ -0,0 +1,55 @@
+package main
+
+import (
+	"math"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestParseSeedRange(t *testing.T) {
+	maxSeed := strconv.FormatInt(math.MaxInt64, 10)
+	minSeed := strconv.FormatInt(math.MinInt64, 10)
+	tests := []struct {
+		s string
+		want []i64  // nil for an error
+	}{
+		{"42", []i64{42}},
+		{"0", []i64{0}},
+		{"3-5", []i64{3, 4, 5}},
+		{"7-7", []i64{7}},
+		{"-5", []i64{-5}},
+		{"-2-1", []i64{-2, -1, 0, 1}},
+		{"-5--3", []i64{-5, -4, -3}},
+		{maxSeed, []i64{math.MaxInt64}},
+		{maxSeed + "-" + maxSeed, []i64{math.MaxInt64}},
+		{minSeed + "-" + minSeed, []i64{math.MinInt64}},
+		{strconv.FormatInt(math.MaxInt64 - 1, 10) + "-" + maxSeed, []i64{math.MaxInt64 - 1, math.MaxInt64}},
+		{"5-3", nil},
+		{"0-" + maxSeed, nil},
+		{minSeed + "-" + maxSeed, nil},
+		{"1-" + strconv.Itoa(MAX_SOLVE_SEEDS + 1), nil},
+		{"", nil},
+		{"-", nil},
+		{"1-", nil},
+		{"a-b", nil},
+		{"1--", nil},
+	}
+	for _, test := range tests {
+		seeds, err := parseSeedRange(test.s)
+		if test.want == nil {
+			if err == nil { t.Errorf("parseSeedRange(%q) = %d seeds, want an error", test.s, len(seeds)) }
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseSeedRange(%q): %v", test.s, err)
+		} else if !reflect.DeepEqual(seeds, test.want) {
+			t.Errorf("parseSeedRange(%q) = %v, want %v", test.s, seeds, test.want)
+		}
+	}
+
+	seeds, err := parseSeedRange("1-" + strconv.Itoa(MAX_SOLVE_SEEDS))
+	if err != nil || len(seeds) != MAX_SOLVE_SEEDS {
+		t.Errorf("parseSeedRange of MAX_SOLVE_SEEDS seeds = %d seeds, %v", len(seeds), err)
+	}
+}
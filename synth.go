@@ -0,0 +1,219 @@
+package main
+
+import (
+	"github.com/gen2brain/raylib-go/raylib"
+	"bytes"
+	"encoding/binary"
+	"math"
+	"math/rand"
+	"strings"
+)
+
+// Sound effects and music synthesized at startup, used in place of the sound and music
+// assets that are missing and for all of them with the "retro" audio theme of the settings.
+// A synth returns the samples of a mono effect at SYNTH_SAMPLE_RATE, normalized by synthSound.
+type Synth func() []f32
+
+const (
+	SYNTH_SAMPLE_RATE = 22050
+	RETRO_AUDIO_THEME = "retro"
+	SYNTH_PEAK = 0.8
+	MUSIC_PEAK = 0.5     // under the sound effects
+	NOTE_RELEASE = 0.02  // seconds of fade at the end of a note, against clicks
+)
+
+type Waveform func(phase f64) f64  // phase in cycles
+
+func sine(phase f64) f64 { return math.Sin(2 * math.Pi * phase) }
+func square(phase f64) f64 {
+	if math.Mod(phase, 1) < 0.5 { return 1 }
+	return -1
+}
+func triangle(phase f64) f64 { return 4*math.Abs(math.Mod(phase + 0.25, 1) - 0.5) - 1 }
+
+// A tone sweeping from freq0 to freq1 Hz in duration seconds, with a linear attack
+// and an exponential decay
+func tone(wave Waveform, freq0, freq1, duration, attack, decay f64) []f32 {
+	n := int(duration * SYNTH_SAMPLE_RATE)
+	samples := make([]f32, n)
+	phase := 0.0
+	for i := range samples {
+		t := f64(i) / SYNTH_SAMPLE_RATE
+		freq := freq0 + (freq1 - freq0) * t / duration
+		phase += freq / SYNTH_SAMPLE_RATE
+
+		env := math.Exp(-decay * t)
+		if t < attack { env *= t / attack }
+		samples[i] = f32(wave(phase) * env)
+	}
+	return samples
+}
+
+// White noise decaying exponentially
+func noise(duration, decay f64) []f32 {
+	rng := rand.New(rand.NewSource(1))
+	samples := make([]f32, int(duration * SYNTH_SAMPLE_RATE))
+	for i := range samples {
+		t := f64(i) / SYNTH_SAMPLE_RATE
+		samples[i] = f32((rng.Float64()*2 - 1) * math.Exp(-decay * t))
+	}
+	return samples
+}
+
+// Adds b scaled by gain to a, extending a as needed
+func mix(a, b []f32, gain f32) []f32 {
+	for len(a) < len(b) { a = append(a, 0) }
+	for i := range b { a[i] += b[i] * gain }
+	return a
+}
+
+// Plays the notes(in Hz) one after another, each lasting step seconds
+func arpeggio(wave Waveform, notes []f64, step, decay f64) []f32 {
+	var samples []f32
+	for _, note := range notes {
+		samples = append(samples, tone(wave, note, note, step, 0.005, decay)...)
+	}
+	return samples
+}
+
+func synthJump() []f32 { return tone(square, 280, 760, 0.16, 0.005, 8) }
+func synthBigJump() []f32 {
+	return mix(tone(square, 180, 1100, 0.4, 0.01, 3), tone(sine, 90, 550, 0.4, 0.01, 3), 0.5)
+}
+func synthLand() []f32 { return mix(tone(sine, 140, 60, 0.12, 0.002, 25), noise(0.06, 60), 0.3) }
+func synthBigLand() []f32 {
+	return mix(tone(sine, 110, 40, 0.35, 0.002, 9), noise(0.25, 14), 0.45)
+}
+func synthTitleJump() []f32 { return tone(triangle, 220, 880, 0.3, 0.01, 4) }
+func synthTitleLand() []f32 { return mix(tone(sine, 90, 35, 0.5, 0.002, 6), noise(0.35, 10), 0.5) }
+func synthStart() []f32 { return arpeggio(square, []f64{523.3, 784, 1046.5}, 0.07, 12) }
+func synthYay() []f32 { return arpeggio(triangle, []f64{659.3, 880}, 0.09, 10) }
+func synthSuccess() []f32 {
+	return arpeggio(square, []f64{523.3, 659.3, 784, 1046.5, 784, 1046.5}, 0.11, 6)
+}
+func synthFail() []f32 {
+	return append(tone(square, 392, 370, 0.22, 0.005, 3), tone(square, 311, 260, 0.45, 0.005, 2.5)...)
+}
+
+// The frequency in Hz of a note like "C4", "F#3" or "Bb2", A4 being 440 Hz
+func noteFreq(note string) f64 {
+	semitone := map[byte]int{'C': -9, 'D': -7, 'E': -5, 'F': -4, 'G': -2, 'A': 0, 'B': 2}[note[0]]
+	switch note[1] {
+	case '#':
+		semitone++
+		note = note[1:]
+	case 'b':
+		semitone--
+		note = note[1:]
+	}
+	octave := int(note[1] - '0')
+	return 440 * math.Pow(2, f64(semitone + 12*(octave - 4))/12)
+}
+
+// Plays the space separated notes(see noteFreq) one per step of step seconds.
+// A note rings until the next one, "-" holds the note(or the silence) for one more step.
+func sequence(wave Waveform, line string, step, decay f64) []f32 {
+	steps := strings.Fields(line)
+	stepLen := int(step * SYNTH_SAMPLE_RATE)
+	samples := make([]f32, len(steps) * stepLen)
+	for i := 0; i < len(steps); {
+		j := i + 1
+		for j < len(steps) && steps[j] == "-" { j++ }
+		if steps[i] != "-" {
+			freq := noteFreq(steps[i])
+			note := tone(wave, freq, freq, f64((j - i) * stepLen) / SYNTH_SAMPLE_RATE, 0.005, decay)
+			release := int(NOTE_RELEASE * SYNTH_SAMPLE_RATE)
+			for k := 0; k < release && k < len(note); k++ { note[len(note) - 1 - k] *= f32(k) / f32(release) }
+			copy(samples[i * stepLen:], note)
+		}
+		i = j
+	}
+	return samples
+}
+
+// A looping music track: a square melody over a triangle bass, a line of notes per bar.
+// The bass loops for as long as the melody lasts.
+type Tune struct {
+	step f64  // seconds
+	melody, bass []string
+}
+
+func (tune Tune) synth() []f32 {
+	melody := sequence(square, strings.Join(tune.melody, " "), tune.step, 2.5)
+	bass := sequence(triangle, strings.Join(tune.bass, " "), tune.step, 1.5)
+	for len(bass) > 0 && len(bass) < len(melody) { bass = append(bass, bass...) }
+	if len(bass) > len(melody) { bass = bass[:len(melody)] }
+	return mix(melody, bass, 1.2)
+}
+
+var titleTune = Tune{step: 0.3,
+	melody: []string{
+		"E5 - G5 - C6 - G5 -", "A5 - E5 - C5 - E5 -", "F5 - A5 - C6 - A5 -", "G5 - B5 - D6 - B5 -",
+		"E5 - G5 - C6 - E6 -", "C6 - A5 - E5 - A5 -", "F5 - A5 - C6 - D6 -", "B5 - G5 - D5 - - -",
+	},
+	bass: []string{"C3 - - - G2 - - -", "A2 - - - E2 - - -", "F2 - - - C3 - - -", "G2 - - - D3 - - -"},
+}
+
+var playTune = Tune{step: 0.18,
+	melody: []string{
+		"C5 E5 G5 E5 C5 E5 G5 C6", "B4 D5 G5 D5 B4 D5 G5 B5",
+		"A4 C5 E5 C5 A4 C5 E5 A5", "F4 A4 C5 A4 F4 A4 C5 F5",
+		"G5 - E5 - C5 D5 E5 -", "D5 - B4 - G4 A4 B4 -",
+		"C5 - A4 - E5 - C5 -", "A4 - C5 - F5 E5 D5 -",
+	},
+	bass: []string{"C3 - C4 - C3 - C4 -", "G2 - G3 - G2 - G3 -", "A2 - A3 - A2 - A3 -", "F2 - F3 - F2 - F3 -"},
+}
+
+var endTune = Tune{step: 0.4,
+	melody: []string{"A5 - - - C6 - - -", "B5 - - - D6 - - -", "C6 - G5 - E5 - C5 -", "E5 - - - - - - -"},
+	bass: []string{"F2 - - - F3 - - -", "G2 - - - G3 - - -", "C3 - - - G2 - - -", "C3 - - - - - - -"},
+}
+
+// The samples normalized to peak, as signed 16 bit little endian PCM
+func pcm16(samples []f32, peak f32) []byte {
+	max := f32(0)
+	for _, s := range samples {
+		if s > max { max = s }
+		if -s > max { max = -s }
+	}
+	gain := f32(0)
+	if max > 0 { gain = peak / max }
+
+	data := make([]byte, 2*len(samples))
+	for i, s := range samples {
+		binary.LittleEndian.PutUint16(data[2*i:], uint16(int16(s * gain * math.MaxInt16)))
+	}
+	return data
+}
+
+// Makes a sound of the samples, normalized and at 16 bits
+func synthSound(synth Synth) rl.Sound {
+	samples := synth()
+	data := pcm16(samples, SYNTH_PEAK)
+	// the wave points to data and is copied by LoadSoundFromWave, so it's not unloaded
+	wave := rl.NewWave(uint32(len(samples)), SYNTH_SAMPLE_RATE, 16, 1, data)
+	return rl.LoadSoundFromWave(wave)
+}
+
+// Makes a mono 16 bit WAV file of the samples, for the music streamed from memory
+func synthWav(synth Synth) []byte {
+	data := pcm16(synth(), MUSIC_PEAK)
+	header := struct {
+		Riff [4]byte
+		Size uint32
+		Wave, Fmt [4]byte
+		FmtSize uint32
+		Format, Channels uint16
+		SampleRate, ByteRate uint32
+		BlockAlign, BitsPerSample uint16
+		Data [4]byte
+		DataSize uint32
+	}{
+		[4]byte{'R', 'I', 'F', 'F'}, uint32(36 + len(data)), [4]byte{'W', 'A', 'V', 'E'}, [4]byte{'f', 'm', 't', ' '},
+		16, 1, 1, SYNTH_SAMPLE_RATE, 2*SYNTH_SAMPLE_RATE, 2, 16, [4]byte{'d', 'a', 't', 'a'}, uint32(len(data)),
+	}
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.LittleEndian, &header)
+	buf.Write(data)
+	return buf.Bytes()
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestNoteFreq(t *testing.T) {
+	tests := []struct {
+		note string
+		want f64
+	}{
+		{"A4", 440},
+		{"A5", 880},
+		{"A2", 110},
+		{"C4", 261.63},
+		{"C#4", 277.18},
+		{"Db4", 277.18},
+		{"Bb2", 116.54},
+		{"B5", 987.77},
+	}
+	for _, test := range tests {
+		if got := noteFreq(test.note); math.Abs(got - test.want) > 0.01 {
+			t.Errorf("noteFreq(%q) = %.2f, want %.2f", test.note, got, test.want)
+		}
+	}
+}
+
+func TestSequenceHoldsAndRests(t *testing.T) {
+	step := 0.1
+	stepLen := int(step * SYNTH_SAMPLE_RATE)
+	samples := sequence(sine, "- - A4 - - C5", step, 1)
+	if len(samples) != 6*stepLen { t.Fatalf("got %d samples, want %d", len(samples), 6*stepLen) }
+
+	for i, s := range samples[:2*stepLen] {
+		if s != 0 { t.Fatalf("sample %d of the leading rest is %v", i, s) }
+	}
+	// the A4 rings over the held steps and fades out before the C5
+	if s := samples[4*stepLen + stepLen/2]; s == 0 { t.Error("the A4 doesn't ring over the held steps") }
+	if s := samples[5*stepLen - 1]; math.Abs(f64(s)) > 0.01 { t.Errorf("the A4 ends at %v, not faded out", s) }
+}
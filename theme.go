@@ -0,0 +1,118 @@
+package main
+
+import (
+	"github.com/gen2brain/raylib-go/raylib"
+	"encoding/json"
+	"fmt"
+	"path"
+	"reflect"
+	"sort"
+)
+
+// A theme is a set of the textures: the animals sheet, the background, the title logo
+// and the dust. Each theme is a directory in the assets' THEME_DIR with a theme.json
+// manifest naming its files relative to the directory, e.g.
+//     {"name": "Night", "background": "background.png"}
+// The textures a theme doesn't name are the ones of the default theme.
+type Theme struct {
+	Name string `json:"name"`
+	Animals string `json:"animals"`  // the atlas manifest of the animals sheet
+	Background string `json:"background"`
+	Title string `json:"title"`
+	Dust string `json:"dust"`
+}
+
+const (
+	THEME_DIR = "themes"
+	THEME_MANIFEST = "theme.json"
+	DEFAULT_THEME = "default"
+)
+
+var defaultTheme = Theme{
+	Name: "Default",
+	Animals: "textures/animals.json",
+	Background: "textures/background.png",
+	Title: "textures/title.png",
+	Dust: "textures/dust.png",
+}
+
+var themeID string  // the directory name of the current theme
+
+func loadTheme(id string) (Theme, error) {
+	if id == "" || id == DEFAULT_THEME { return defaultTheme, nil }
+
+	dir := path.Join(THEME_DIR, id)
+	data, err := readAsset(path.Join(dir, THEME_MANIFEST))
+	if err != nil { return Theme{}, err }
+	var manifest Theme
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return Theme{}, fmt.Errorf("%s/%s: %v", id, THEME_MANIFEST, err)
+	}
+
+	theme := defaultTheme
+	theme.Name = id
+	if manifest.Name != "" { theme.Name = manifest.Name }
+	for _, f := range []struct{ dst *string; src string }{
+		{&theme.Animals, manifest.Animals},
+		{&theme.Background, manifest.Background},
+		{&theme.Title, manifest.Title},
+		{&theme.Dust, manifest.Dust},
+	} {
+		if f.src != "" { *f.dst = path.Join(dir, f.src) }
+	}
+	return theme, nil
+}
+
+// Returns the ids of the available themes, the default one first
+func listThemes() []string {
+	var ids []string
+	for _, entry := range listAssetDir(THEME_DIR) {
+		if entry.IsDir() && entry.Name() != DEFAULT_THEME { ids = append(ids, entry.Name()) }
+	}
+	sort.Strings(ids)
+	return append([]string{DEFAULT_THEME}, ids...)
+}
+
+// Switches to the theme id without restarting, keeping the current textures
+// if the ones of the theme can't be made
+func applyTheme(id string) error {
+	theme, err := loadTheme(id)
+	if err != nil { return err }
+
+	var report AssetReport
+	themeTextures, atlas, err := loadTextures(theme, &report)
+	report.print()
+	if err != nil {
+		unloadTextures(themeTextures)
+		return err
+	}
+	unloadTextures(textures)
+	textures, animalsAtlas, themeID = themeTextures, atlas, id
+	return nil
+}
+
+// Switches to the next available theme and saves it in the settings
+func cycleTheme() {
+	ids := listThemes()
+	next := ids[0]
+	for i, id := range ids {
+		if id == themeID && i + 1 < len(ids) { next = ids[i + 1] }
+	}
+	if next == themeID { return }
+
+	if err := applyTheme(next); err != nil {
+		fmt.Printf("Failed to apply the theme %q: %v\n", next, err)
+		return
+	}
+	settings.Theme = themeID
+	if themeID == DEFAULT_THEME { settings.Theme = "" }
+	saveSettingsOrReport()
+}
+
+func unloadTextures(tx Textures) {
+	t := reflect.ValueOf(tx)
+	for i := 0; i < t.NumField(); i++ {
+		texture := t.Field(i).Interface().(rl.Texture2D)
+		if texture.ID != 0 { rl.UnloadTexture(texture) }
+	}
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// A timeline is a list of keyframed actions played on the title logo and the title animals,
+// loaded from a JSON file so the intro can be changed without touching the code.
+//
+// Actions and their fields:
+//   "drop"      animal: jump to "to"(its place on the title by default) with duration and peak
+//   "jump"      animal: jump to "to" with duration and peak
+//   "push"      animal: slide sideway to "to"(only X is used) in duration
+//   "press"     animal: squash by amount, in ANIM_SIZE
+//   "dropLogo"  drop the title logo and let it bounce
+//   "pressLogo" squash the title logo by amount, in TITLE_HEIGHT
+//   "sound"     play the sound with the field name of Sounds, e.g. "TitleLand"
+//
+// "to" is one of "self"(default), "dest", "rescueSpot" or "animal {n}", offset by dx and dy.
+type Timeline struct {
+	Name string `json:"name"`
+	Keyframes []Keyframe `json:"keyframes"`
+}
+
+type Keyframe struct {
+	Time f32 `json:"time"`  // seconds from the start of the timeline
+	Action string `json:"action"`
+	Animal int `json:"animal"`
+	To string `json:"to"`
+	DX f32 `json:"dx"`
+	DY f32 `json:"dy"`
+	Duration f32 `json:"duration"`
+	Peak f32 `json:"peak"`
+	Amount f32 `json:"amount"`
+	Sound string `json:"sound"`
+}
+
+type TimelinePlayer struct {
+	timeline *Timeline
+	elapsed f32
+	next int  // index of the next keyframe to run
+}
+
+const (
+	NUM_TITLE_ANIMS = 3
+	TITLE_TIMELINE = "timelines/title.json"  // asset path
+)
+
+// Loads the timeline of path, read with read(readAsset for the assets, os.ReadFile for the files)
+func loadTimeline(path string, read func(string) ([]byte, error)) (*Timeline, error) {
+	data, err := read(path)
+	if err != nil { return nil, err }
+
+	var tl Timeline
+	if err := json.Unmarshal(data, &tl); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	for i := range tl.Keyframes {
+		if err := validateKeyframe(&tl.Keyframes[i]); err != nil {
+			return nil, fmt.Errorf("%s: keyframe %d: %v", path, i, err)
+		}
+	}
+	sort.SliceStable(tl.Keyframes, func(i, j int) bool {
+		return tl.Keyframes[i].Time < tl.Keyframes[j].Time
+	})
+
+	return &tl, nil
+}
+
+func validateKeyframe(kf *Keyframe) error {
+	if kf.Action == "drop" && kf.To == "" { kf.To = "dest" }
+
+	switch kf.Action {
+	case "drop", "jump", "push":
+		if kf.Duration <= 0 { return fmt.Errorf("%s needs a positive duration", kf.Action) }
+		if _, err := parseTimelineTarget(kf.To); err != nil { return err }
+	case "press", "dropLogo", "pressLogo":
+	case "sound":
+		if _, ok := soundByName(kf.Sound); !ok { return fmt.Errorf("unknown sound %q", kf.Sound) }
+	default:
+		return fmt.Errorf("unknown action %q", kf.Action)
+	}
+	if kf.Animal < 0 || kf.Animal >= NUM_TITLE_ANIMS {
+		return fmt.Errorf("animal %d is out of range", kf.Animal)
+	}
+	return nil
+}
+
+// Returns the base of a target, with -1 to -3 for "self", "dest" and "rescueSpot"
+// and the index of the animal for "animal {n}"
+func parseTimelineTarget(to string) (int, error) {
+	switch to {
+	case "", "self":
+		return -1, nil
+	case "dest":
+		return -2, nil
+	case "rescueSpot":
+		return -3, nil
+	}
+	if strings.HasPrefix(to, "animal ") {
+		n, err := strconv.Atoi(strings.TrimPrefix(to, "animal "))
+		if err == nil && n >= 0 && n < NUM_TITLE_ANIMS { return n, nil }
+	}
+	return 0, fmt.Errorf("invalid target %q", to)
+}
+
+func timelineTarget(kf *Keyframe, anims *[NUM_TITLE_ANIMS]*Animal) Vec2 {
+	anim := anims[kf.Animal]
+	base, _ := parseTimelineTarget(kf.To)
+	var pos Vec2
+	switch base {
+	case -1:
+		pos = anim.pos
+	case -2:
+		pos = anim.dest
+	case -3:
+		pos = Vec2{RESQUE_SPOT_X, RESQUE_SPOT_Y}
+	default:
+		pos = anims[base].pos
+	}
+	return Vec2Add(pos, Vec2{kf.DX, kf.DY})
+}
+
+func newTimelinePlayer(tl *Timeline) TimelinePlayer {
+	if tl == nil { tl = &Timeline{} }
+	return TimelinePlayer{timeline: tl}
+}
+
+func (tp *TimelinePlayer) done() bool { return tp.next >= len(tp.timeline.Keyframes) }
+
+// Advances the timeline by dt seconds, running the keyframes that became due
+func (tp *TimelinePlayer) update(dt f32, title *TitleLogo, anims *[NUM_TITLE_ANIMS]*Animal) {
+	tp.elapsed += dt
+	for !tp.done() && tp.timeline.Keyframes[tp.next].Time <= tp.elapsed {
+		runKeyframe(&tp.timeline.Keyframes[tp.next], title, anims)
+		tp.next++
+	}
+}
+
+func runKeyframe(kf *Keyframe, title *TitleLogo, anims *[NUM_TITLE_ANIMS]*Animal) {
+	anim := anims[kf.Animal]
+
+	switch kf.Action {
+	case "drop", "jump":
+		jumpAnimal(anim, timelineTarget(kf, anims), kf.Duration, kf.Peak)
+	case "push":
+		anim.dest = Vec2{timelineTarget(kf, anims).X, anim.pos.Y}
+		tweenTo(&anim.pos.X, anim.dest.X, kf.Duration, easeOutQuad)
+	case "press":
+		squash(&anim.height, ANIM_SIZE, kf.Amount*ANIM_SIZE, MIN_ANIM_HEIGHT)
+	case "dropLogo":
+		dropTitle(title)
+	case "pressLogo":
+		squash(&title.height, TITLE_HEIGHT, kf.Amount*TITLE_HEIGHT, MIN_TITLE_HEIGHT)
+	case "sound":
+		playSound(kf.Sound)
+	}
+}
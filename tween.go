@@ -0,0 +1,147 @@
+package main
+
+import (
+	"math"
+)
+
+// Easing maps the progress of a tween in [0, 1] to the progress of its value,
+// 0 at the start and 1 at the end(it may overshoot in between, e.g. easeOutElastic).
+type Easing func(t f32) f32
+
+func easeLinear(t f32) f32 { return t }
+func easeInQuad(t f32) f32 { return t * t }
+func easeOutQuad(t f32) f32 { return t * (2 - t) }
+func easeInOutQuad(t f32) f32 {
+	if t < 0.5 { return 2 * t * t }
+	return 1 - 2 * (1 - t) * (1 - t)
+}
+func easeOutExpo(t f32) f32 {
+	if t >= 1 { return 1 }
+	return 1 - f32(math.Pow(2, f64(-10 * t)))
+}
+
+// The progress easeOutBounce first reaches 1 at
+const BOUNCE_FIRST_LANDING = 1/2.75
+
+func easeOutBounce(t f32) f32 {
+	const n, d = 7.5625, 2.75
+	switch {
+	case t < 1/d:
+		return n * t * t
+	case t < 2/d:
+		t -= 1.5/d
+		return n * t * t + 0.75
+	case t < 2.5/d:
+		t -= 2.25/d
+		return n * t * t + 0.9375
+	default:
+		t -= 2.625/d
+		return n * t * t + 0.984375
+	}
+}
+
+func easeOutElastic(t f32) f32 {
+	if t <= 0 || t >= 1 { return t }
+	return f32(math.Pow(2, f64(-10 * t)) * math.Sin((f64(t) * 10 - 0.75) * 2 * math.Pi / 3)) + 1
+}
+
+// A damped spring that overshoots a little and settles on 1
+func easeSpring(t f32) f32 {
+	if t >= 1 { return 1 }
+	return 1 - f32(math.Exp(-6 * f64(t)) * math.Cos(12 * f64(t)))
+}
+
+// A tween drives a f32 property(pos.X, height, alpha...) from its value at the start
+// of the tween to the given value over duration seconds. When it completes, onDone
+// is called and the tweens chained with then are started.
+type Tween struct {
+	target *f32
+	from f32
+	to f32
+	duration f32
+	elapsed f32
+	ease Easing
+	onDone func()
+	next []*Tween
+	cancelled bool
+}
+
+var tweens []*Tween
+
+// Starts a tween on target, replacing any other tween running on it
+func tweenTo(target *f32, to, duration f32, ease Easing) *Tween {
+	tw := &Tween{target: target, to: to, duration: duration, ease: ease}
+	startTween(tw)
+	return tw
+}
+
+// Chains a tween that starts when tw completes, from the value target has by then
+func (tw *Tween) then(target *f32, to, duration f32, ease Easing) *Tween {
+	next := &Tween{target: target, to: to, duration: duration, ease: ease}
+	tw.next = append(tw.next, next)
+	return next
+}
+
+func startTween(tw *Tween) {
+	cancelTweens(tw.target)
+	tw.from = *tw.target
+	tweens = append(tweens, tw)
+}
+
+// Cancels the tweens running on target along with the ones chained to them
+func cancelTweens(target *f32) {
+	for _, tw := range tweens {
+		if tw.target == target { tw.cancelled = true }
+	}
+}
+
+func isTweening(target *f32) bool {
+	for _, tw := range tweens {
+		if tw.target == target && !tw.cancelled { return true }
+	}
+	return false
+}
+
+// Returns the value the running tween on target is heading to
+func tweenTarget(target *f32) (f32, bool) {
+	for _, tw := range tweens {
+		if tw.target == target && !tw.cancelled { return tw.to, true }
+	}
+	return 0, false
+}
+
+// Advances all the tweens by dt seconds. Tweens started by the completion
+// of others begin on the next update.
+func updateTweens(dt f32) {
+	running := tweens
+	for _, tw := range running {
+		if tw.cancelled { continue }
+
+		tw.elapsed += dt
+		t := f32(1)
+		if tw.duration > 0 && tw.elapsed < tw.duration { t = tw.elapsed / tw.duration }
+		*tw.target = lerp(tw.from, tw.to, tw.ease(t))
+
+		if t >= 1 {
+			tw.cancelled = true
+			for _, next := range tw.next { startTween(next) }
+			if tw.onDone != nil { tw.onDone() }
+		}
+	}
+
+	alive := tweens[:0]
+	for _, tw := range tweens {
+		if !tw.cancelled { alive = append(alive, tw) }
+	}
+	for i := len(alive); i < len(tweens); i++ { tweens[i] = nil }
+	tweens = alive
+}
+
+// Presses a height down by amount(not below minHeight) and lets it spring back to fullHeight,
+// wobbling a little around it. Returns the tween of the recovery.
+func squash(height *f32, fullHeight, amount, minHeight f32) *Tween {
+	pressed := *height - amount
+	if pressed < minHeight { pressed = minHeight }
+	return tweenTo(height, pressed, SQUASH_DURATION, easeOutExpo).
+		   then(height, fullHeight, SPRING_DURATION, easeSpring)
+}
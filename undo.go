@@ -0,0 +1,47 @@
+package main
+
+// Undo takes back the rescues one at a time. The state of the play is saved before each
+// rescue and restored when the animals are settled, sliding them back to where they were.
+type UndoState struct {
+	board [BOARD_SIZE]*Animal
+	resqued [BOARD_SIZE]*Animal
+	pos [BOARD_SIZE]Vec2  // of the animals, by their index in animals
+	numAnimalLeft int
+	bigJumpLeft int
+	bigJumpMade bool
+	lastMsgShown bool
+}
+
+const UNDO_DURATION = 0.3
+
+var undoStack []UndoState
+
+func pushUndo(animals *[BOARD_SIZE]Animal, board, resqued *[BOARD_SIZE]*Animal,
+			  numAnimalLeft, bigJumpLeft int, bigJumpMade, lastMsgShown bool) {
+	u := UndoState{board: *board, resqued: *resqued, numAnimalLeft: numAnimalLeft,
+				   bigJumpLeft: bigJumpLeft, bigJumpMade: bigJumpMade, lastMsgShown: lastMsgShown}
+	for i := range animals { u.pos[i] = animals[i].pos }
+	undoStack = append(undoStack, u)
+}
+
+// Restores the state before the last rescue. Returns false if there is none.
+func popUndo(animals *[BOARD_SIZE]Animal, board, resqued *[BOARD_SIZE]*Animal,
+			 numAnimalLeft, bigJumpLeft *int, bigJumpMade, lastMsgShown *bool) bool {
+	if len(undoStack) == 0 { return false }
+	u := undoStack[len(undoStack) - 1]
+	undoStack = undoStack[:len(undoStack) - 1]
+
+	*board, *resqued = u.board, u.resqued
+	*numAnimalLeft, *bigJumpLeft = u.numAnimalLeft, u.bigJumpLeft
+	*bigJumpMade, *lastMsgShown = u.bigJumpMade, u.lastMsgShown
+
+	for i := range animals {
+		anim := &animals[i]
+		anim.jump, anim.bigJump, anim.scale = Jump{}, false, 1
+		anim.dest = u.pos[i]
+		tweenTo(&anim.pos.X, u.pos[i].X, UNDO_DURATION, easeInOutQuad)
+		tweenTo(&anim.pos.Y, u.pos[i].Y, UNDO_DURATION, easeInOutQuad)
+		tweenTo(&anim.height, ANIM_SIZE, UNDO_DURATION, easeOutQuad)
+	}
+	return true
+}